@@ -1,273 +1,746 @@
 package main
 
 import (
-	"encoding/json"
+	"database/sql"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"runtime/debug"
+	"slices"
+	"strconv"
 	"strings"
-
-	"github.com/charmbracelet/bubbles/table"
-	"github.com/charmbracelet/bubbles/textinput"
-	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
+	"unicode/utf8"
 
 	"mcold/tel/config"
 	"mcold/tel/db"
+	"mcold/tel/internal/logrotate"
+	"mcold/tel/pkg/tel"
+)
+
+// Set at build time with -ldflags "-X main.version=... -X main.commit=... -X main.date=...".
+var (
+	version = "dev"
+	commit  = ""
+	date    = ""
 )
 
-func applyColumnWidths(columns []table.Column, widths map[string]int, aliases map[string]string) []table.Column {
-	for i := range columns {
-		fieldName := columns[i].Title
-		if width, ok := widths[fieldName]; ok {
-			columns[i].Width = width
-		} else {
-			columns[i].Width = 20
+// printVersion prints the build info and compiled-in drivers. commit and date
+// fall back to the VCS stamp Go embeds when they weren't set via -ldflags.
+func printVersion() {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && commit == "":
+				commit = setting.Value
+			case setting.Key == "vcs.time" && date == "":
+				date = setting.Value
+			}
 		}
 	}
-	return columns
+	fmt.Printf("tel %s\n", version)
+	fmt.Printf("commit: %s\n", valueOr(commit, "unknown"))
+	fmt.Printf("built: %s\n", valueOr(date, "unknown"))
+	fmt.Printf("drivers: %s\n", strings.Join(db.Drivers(), ", "))
 }
 
-func main() {
-	// Initialize log file
-	logFilePath := filepath.Join("logs", "tel.log")
-	logFile, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+func valueOr(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
+
+// logMaxBytes returns the log rotation threshold, TEL_LOG_MAX_SIZE_MB or 10 MB.
+func logMaxBytes() int64 {
+	sizeMB := 10
+	if v, err := strconv.Atoi(os.Getenv("TEL_LOG_MAX_SIZE_MB")); err == nil && v > 0 {
+		sizeMB = v
+	}
+	return int64(sizeMB) * 1024 * 1024
+}
+
+// logMaxFiles returns how many rotated logs to keep, TEL_LOG_MAX_FILES or 5.
+func logMaxFiles() int {
+	if v, err := strconv.Atoi(os.Getenv("TEL_LOG_MAX_FILES")); err == nil && v > 0 {
+		return v
+	}
+	return 5
+}
+
+// openLog opens the log file, preferring the -log-file flag, then TEL_LOG_FILE,
+// then logs/tel.log. Logging is discarded if the file can't be opened.
+func openLog(path string) io.WriteCloser {
+	if path == "" {
+		path = os.Getenv("TEL_LOG_FILE")
+	}
+	if path == "" {
+		path = filepath.Join("logs", "tel.log")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "WARN: can't create log directory, logging disabled: %v\n", err)
+		return nopCloser{io.Discard}
+	}
+	logFile, err := logrotate.New(path, logMaxBytes(), logMaxFiles())
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to open log file: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "WARN: can't open log file, logging disabled: %v\n", err)
+		return nopCloser{io.Discard}
 	}
-	defer logFile.Close()
-	log.SetOutput(logFile)
-	log.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds | log.Lshortfile)
+	return logFile
+}
 
-	log.Println("=== Application started ===")
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		runDiff(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "query" {
+		runQuery(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		runConfig(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "stats" {
+		runStats(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "profile" {
+		runProfile(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "report" {
+		runReport(os.Args[2:])
+		return
+	}
+
+	// `tel last`, or tel without arguments, repeats the last run.
+	last := len(os.Args) == 1
+	if len(os.Args) > 1 && os.Args[1] == "last" {
+		last = true
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
 
 	itemName := flag.String("item", "", "Item name for config")
-	sqlName := flag.String("sql", "", "SQL query name in queries table")
+	sqlName := flag.String("sql", "", "SQL query name in queries table, @file.sql, or - to read it from stdin")
+	query := flag.String("query", "", "Inline SQL to run instead of a saved query; -sql becomes a display name")
 	dbName := flag.String("db", "", "Database name in dbs table")
 	filter := flag.String("filter", "", "Initial filter for text input")
 	args := flag.String("args", "", "JSON with placeholder args in SQL query")
 	uid := flag.String("uid", "", "UID to select row by hash from instance table")
-	viewFlag := flag.String("view", "", "View mode: 'row' or 'column'")
+	profile := flag.String("profile", "", "Profile whose db, item, sql and args fill the flags not given (see tel profile)")
+	viewFlag := flag.String("view", "", "View mode: 'row' (or 't') or 'column' (or 'c'); default: the query's")
+	showVersion := flag.Bool("version", false, "Print version, commit, build date and drivers, then exit")
+	drivers := flag.Bool("drivers", false, "List compiled-in SQL drivers and exit")
+	height := flag.Int("height", 0, "Table height in rows (default: query config height, then 10)")
+	maxWidth := flag.Int("maxwidth", 0, "Cap column widths at this many cells (default: query config max_width, else none)")
+	noColor := flag.Bool("no-color", false, "Disable colors (also set by a non-empty NO_COLOR)")
+	themeName := flag.String("theme", "", "Color theme preset: 'dark', 'light' or 'solarized' (default ~/.tel/theme.json or dark)")
+	var dryRun bool
+	flag.BoolVar(&dryRun, "dry-run", false, "Print the composed SQL and exit without executing it")
+	flag.BoolVar(&dryRun, "explain", false, "Alias for -dry-run")
+	logFileFlag := flag.String("log-file", "", "Log file path (default $TEL_LOG_FILE or logs/tel.log)")
+	mouse := flag.Bool("mouse", false, "Enable mouse row selection and wheel scrolling (uses the alternate screen)")
+	live := flag.Bool("live", false, "Re-run the filter as you type, after a short pause, instead of on enter")
+	undoDepth := flag.Int("undo-depth", 10, "Number of config saves Ctrl+Z can undo (0 disables)")
+	cacheRows := flag.Int("cache-rows", 5000, "Filter results of up to this many rows in memory when the filter compares a text column with a string (0 disables)")
+	output := flag.String("output", "", "Write the result set to stdout as 'csv' or 'tsv', or to -output-file as 'parquet' (DuckDB only), instead of starting the TUI")
+	outputFile := flag.String("output-file", "", "File to write -output parquet to")
+	tmplPath := flag.String("template", "", "Render the results with a Go text/template file instead of starting the TUI")
+	fields := flag.String("fields", "", "Comma-separated columns to export with -output, in that order")
+	delimiter := flag.String("delimiter", "", "Single-character field separator for -output, e.g. '|' or ';'")
+	pipe := flag.String("pipe", "", "Shell command to feed the -output results to instead of stdout")
+	noSaveState := flag.Bool("no-save-state", false, "Don't record this run for tel last")
+	noHeader := flag.Bool("no-header", false, "Omit the column title row with -output (no-op in the TUI)")
 	flag.Parse()
 
+	if *showVersion {
+		printVersion()
+		return
+	}
+
+	if *drivers {
+		for _, name := range db.Drivers() {
+			fmt.Println(name)
+		}
+		return
+	}
+
+	if *viewFlag != "" {
+		mode, ok := viewModes[strings.ToLower(*viewFlag)]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "tel: unknown -view %q; use row (r, t) or column (c)\n", *viewFlag)
+			os.Exit(2)
+		}
+		*viewFlag = mode
+	}
+
+	sep, err := parseDelimiter(*delimiter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tel: %v\n", err)
+		os.Exit(2)
+	}
+
+	// Initialize log file
+	logFile := openLog(*logFileFlag)
+	defer logFile.Close()
+	log.SetOutput(logFile)
+	log.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds | log.Lshortfile)
+
+	log.Println("=== Application started ===")
+
+	if *profile != "" {
+		if err := fillFromProfile(*profile, dbName, itemName, sqlName, args); err != nil {
+			log.Printf("ERROR: %v", err)
+			fmt.Fprintf(os.Stderr, "tel: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if last {
+		if err := fillFromLastRun(itemName, sqlName, query, dbName, filter, uid); err != nil {
+			log.Printf("ERROR: %v", err)
+			fmt.Fprintf(os.Stderr, "tel: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	headless := *output != "" || *tmplPath != ""
+	if !last && (*dbName == "" || (*itemName == "" && !headless)) {
+		defaultFromLastRun(dbName, itemName)
+	}
+
 	log.Printf("Parsed flags: item=%q, sql=%q, db=%q, filter=%q, uid=%q",
 		*itemName, *sqlName, *dbName, *filter, *uid)
 
-	if *itemName == "" {
-		log.Println("ERROR: item flag is empty")
-		os.Exit(1)
+	if missing := missingFlags(*itemName, *sqlName, *query, *dbName, headless); len(missing) > 0 {
+		log.Printf("ERROR: missing %s", strings.Join(missing, ", "))
+		fmt.Fprintf(os.Stderr, "tel: missing %s\n%s\n", strings.Join(missing, ", "), usage)
+		os.Exit(2)
 	}
-	log.Printf("itemName: %s", *itemName)
 
-	if *sqlName == "" {
-		log.Println("ERROR: sql flag is empty")
-		os.Exit(1)
+	if *query != "" && *sqlName != "" {
+		fmt.Fprintf(os.Stderr, "WARN: both -sql and -query given; running -query\n")
+		log.Printf("WARN: both -sql=%q and -query given; -query takes precedence", *sqlName)
 	}
-	log.Printf("sqlName: %s", *sqlName)
 
-	if *dbName == "" {
-		log.Println("ERROR: db flag is empty")
+	if headless {
+		var fieldList []string
+		for _, field := range strings.Split(*fields, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				fieldList = append(fieldList, field)
+			}
+		}
+		err = tel.RunHeadless(tel.HeadlessOptions{
+			SQL:        *sqlName,
+			Query:      *query,
+			DB:         *dbName,
+			Filter:     *filter,
+			Args:       *args,
+			UID:        *uid,
+			Output:     *output,
+			OutputFile: *outputFile,
+			Template:   *tmplPath,
+			Fields:     fieldList,
+			Delimiter:  sep,
+			Pipe:       *pipe,
+			NoHeader:   *noHeader,
+			DryRun:     dryRun,
+		})
+	} else {
+		err = tel.Run(tel.Options{
+			Item:      *itemName,
+			SQL:       *sqlName,
+			Query:     *query,
+			DB:        *dbName,
+			Filter:    *filter,
+			Args:      *args,
+			UID:       *uid,
+			View:      *viewFlag,
+			Theme:     *themeName,
+			Height:    *height,
+			MaxWidth:  *maxWidth,
+			Mouse:     *mouse,
+			Live:      *live,
+			CacheRows: *cacheRows,
+			UndoDepth: *undoDepth,
+			NoColor:   *noColor || os.Getenv("NO_COLOR") != "",
+			DryRun:    dryRun,
+			SaveState: !*noSaveState,
+		})
+	}
+	if err != nil {
+		log.Printf("ERROR: %v", err)
+		fmt.Fprintf(os.Stderr, "tel: %v\n", err)
 		os.Exit(1)
 	}
-	log.Printf("dbName: %s", *dbName)
 
+	log.Println("=== Application exited normally ===")
+}
+
+// fillFromLastRun sets the flags not given to their values in the run
+// saved by the previous TUI session.
+func fillFromLastRun(itemName, sqlName, query, dbName, filter, uid *string) error {
 	if err := config.Init(); err != nil {
-		log.Printf("ERROR: config.Init failed: %v", err)
-		os.Exit(1)
+		return fmt.Errorf("config.Init failed: %w", err)
+	}
+	defer config.Close()
+	run, err := config.GetLastRun()
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("no previous run to repeat; see tel -h")
 	}
-	log.Println("Config initialized successfully")
+	if err != nil {
+		return fmt.Errorf("config.GetLastRun failed: %w", err)
+	}
+	log.Printf("Repeating last run: %+v", run)
+	for _, f := range []struct {
+		flag *string
+		val  string
+	}{
+		{itemName, run.Item}, {sqlName, run.SQL}, {query, run.Query},
+		{dbName, run.DB}, {filter, run.Filter}, {uid, run.UID},
+	} {
+		if *f.flag == "" {
+			*f.flag = f.val
+		}
+	}
+	return nil
+}
+
+// viewModes maps the -view values to the modes queries.view stores.
+var viewModes = map[string]string{
+	"row": "r", "r": "r", "table": "r", "t": "r",
+	"column": "c", "c": "c",
+}
+
+const usage = `usage: tel -item <item> -sql <query_name> -db <database> [flags]
+       tel -profile <profile> [flags]
+       tel last [flags]
+Run tel -h for all flags.`
+
+// parseDelimiter returns the -delimiter character, 0 when s is empty. It
+// must be one character that csv.Writer can separate fields with: not a
+// quote, CR, LF or an invalid rune.
+func parseDelimiter(s string) (rune, error) {
+	if s == "" {
+		return 0, nil
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	if utf8.RuneCountInString(s) != 1 || r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+		return 0, fmt.Errorf("-delimiter must be a single character other than a quote, CR or LF, got %q", s)
+	}
+	return r, nil
+}
+
+// missingFlags names the flags a run needs that weren't given. -item is
+// only needed by the TUI, which saves config under it.
+func missingFlags(itemName, sqlName, query, dbName string, headless bool) []string {
+	var missing []string
+	if itemName == "" && !headless {
+		missing = append(missing, "-item")
+	}
+	if sqlName == "" && query == "" {
+		missing = append(missing, "-sql (or -query)")
+	}
+	if dbName == "" {
+		missing = append(missing, "-db")
+	}
+	return missing
+}
 
-	idDB, err := config.GetDBID(*dbName)
+// defaultFromLastRun sets -db and -item, when not given, to the ones the
+// previous TUI session used. Having no previous session isn't an error.
+func defaultFromLastRun(dbName, itemName *string) {
+	if err := config.Init(); err != nil {
+		log.Printf("WARN: config.Init failed: %v", err)
+		return
+	}
+	defer config.Close()
+	run, err := config.GetLastRun()
 	if err != nil {
-		log.Printf("ERROR: config.GetDBID failed for dbName=%s: %v", *dbName, err)
-		os.Exit(1)
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("WARN: config.GetLastRun failed: %v", err)
+		}
+		return
+	}
+	if *dbName == "" && run.DB != "" {
+		log.Printf("Defaulting -db to %q from the last run", run.DB)
+		*dbName = run.DB
+	}
+	if *itemName == "" && run.Item != "" {
+		log.Printf("Defaulting -item to %q from the last run", run.Item)
+		*itemName = run.Item
+	}
+}
+
+// fillFromProfile sets the flags not given to their values in the profile
+// called name.
+func fillFromProfile(name string, dbName, itemName, sqlName, args *string) error {
+	if err := config.Init(); err != nil {
+		return fmt.Errorf("config.Init failed: %w", err)
+	}
+	defer config.Close()
+	p, err := config.GetProfile(name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("no profile %q; see tel profile list", name)
+	}
+	if err != nil {
+		return fmt.Errorf("config.GetProfile failed: %w", err)
+	}
+	log.Printf("Using profile: %+v", p)
+	for _, f := range []struct {
+		flag *string
+		val  string
+	}{
+		{dbName, p.DB}, {itemName, p.Item}, {sqlName, p.SQL}, {args, p.Args},
+	} {
+		if *f.flag == "" {
+			*f.flag = f.val
+		}
 	}
-	log.Printf("idDB: %d", idDB)
+	return nil
+}
+
+// runDiff implements `tel diff -sql <name> -from <uid> -to <uid>`.
+func runDiff(arguments []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	sqlName := fs.String("sql", "", "SQL query name in queries table")
+	from := fs.String("from", "", "UID of the older snapshot")
+	to := fs.String("to", "", "UID of the newer snapshot")
+	noColor := fs.Bool("no-color", false, "Disable colors (also set by a non-empty NO_COLOR)")
+	themeName := fs.String("theme", "", "Color theme preset: 'dark', 'light' or 'solarized'")
+	logFileFlag := fs.String("log-file", "", "Log file path (default $TEL_LOG_FILE or logs/tel.log)")
+	fs.Parse(arguments)
+
+	logFile := openLog(*logFileFlag)
+	defer logFile.Close()
+	log.SetOutput(logFile)
+	log.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds | log.Lshortfile)
+	log.Printf("diff: sql=%q, from=%q, to=%q", *sqlName, *from, *to)
 
-	idItem, err := config.GetItemID(*itemName)
+	err := tel.RunDiff(tel.DiffOptions{SQL: *sqlName, From: *from, To: *to, Theme: *themeName, NoColor: *noColor || os.Getenv("NO_COLOR") != ""})
 	if err != nil {
-		log.Printf("ERROR: config.GetItemID failed for itemName=%s: %v", *itemName, err)
+		log.Printf("ERROR: %v", err)
+		fmt.Fprintf(os.Stderr, "tel: %v\n", err)
 		os.Exit(1)
 	}
-	log.Printf("idItem: %d", idItem)
+}
 
-	idQuery, err := config.GetQueryID(*sqlName)
-	if err != nil {
-		log.Printf("ERROR: config.GetQueryID failed for sqlName=%s: %v", *sqlName, err)
+// runStats implements `tel stats -db <name> -table <name>`.
+func runStats(arguments []string) {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	dbName := fs.String("db", "", "Database name in dbs table")
+	tableName := fs.String("table", "", "Table to report on, e.g. users or public.users")
+	logFileFlag := fs.String("log-file", "", "Log file path (default $TEL_LOG_FILE or logs/tel.log)")
+	fs.Parse(arguments)
+	if *dbName == "" || *tableName == "" {
+		fmt.Fprintln(os.Stderr, "usage: tel stats -db <name> -table <name>")
+		os.Exit(2)
+	}
+
+	logFile := openLog(*logFileFlag)
+	defer logFile.Close()
+	log.SetOutput(logFile)
+	log.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds | log.Lshortfile)
+	log.Printf("stats: db=%q, table=%q", *dbName, *tableName)
+
+	if err := tel.RunStats(tel.StatsOptions{DB: *dbName, Table: *tableName}); err != nil {
+		log.Printf("ERROR: %v", err)
+		fmt.Fprintf(os.Stderr, "tel: %v\n", err)
 		os.Exit(1)
 	}
-	log.Printf("idQuery: %d", idQuery)
+}
+
+// runQuery implements `tel query clone [-db <name>] <src> <dst>`.
+func runQuery(arguments []string) {
+	if len(arguments) == 0 || arguments[0] != "clone" {
+		fmt.Fprintln(os.Stderr, "usage: tel query clone [-db <name>] <src> <dst>")
+		os.Exit(2)
+	}
+	fs := flag.NewFlagSet("query clone", flag.ExitOnError)
+	dbName := fs.String("db", "", "Database name whose item the copy belongs to (default: the source's)")
+	fs.Parse(arguments[1:])
+	if fs.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "usage: tel query clone [-db <name>] <src> <dst>")
+		os.Exit(2)
+	}
+	src, dst := fs.Arg(0), fs.Arg(1)
 
-	driver, err := config.GetDBDriverByID(idDB)
+	if err := config.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "tel: config.Init failed: %v\n", err)
+		os.Exit(1)
+	}
+	var err error
+	if *dbName != "" {
+		err = config.CloneQueryToDB(src, dst, *dbName)
+	} else {
+		err = config.CloneQuery(src, dst)
+	}
+	config.Close()
 	if err != nil {
-		log.Printf("ERROR: config.GetDBDriverByID failed for idDB=%d: %v", idDB, err)
+		fmt.Fprintf(os.Stderr, "tel: %v\n", err)
 		os.Exit(1)
 	}
-	log.Printf("driver: %s", driver)
+	fmt.Printf("cloned %s to %s\n", src, dst)
+}
+
+// runConfig implements the `tel config merge`, `export-queries` and
+// `import-queries` subcommands.
+func runConfig(arguments []string) {
+	if len(arguments) == 0 {
+		fmt.Fprintln(os.Stderr, configUsage)
+		os.Exit(2)
+	}
+	switch arguments[0] {
+	case "merge":
+		runConfigMerge(arguments[1:])
+	case "export-queries":
+		runConfigExport(arguments[1:])
+	case "import-queries":
+		runConfigImport(arguments[1:])
+	default:
+		fmt.Fprintln(os.Stderr, configUsage)
+		os.Exit(2)
+	}
+}
+
+const configUsage = `usage: tel config merge -from <path> [-overwrite]
+       tel config export-queries [-db <name>] [-o <file.sql>]
+       tel config import-queries [-overwrite] <file.sql>`
+
+func runConfigMerge(arguments []string) {
+	fs := flag.NewFlagSet("config merge", flag.ExitOnError)
+	from := fs.String("from", "", "tel.db to import dbs, items and queries from")
+	overwrite := fs.Bool("overwrite", false, "Replace rows whose names already exist instead of skipping them")
+	fs.Parse(arguments)
+	if *from == "" {
+		fmt.Fprintln(os.Stderr, configUsage)
+		os.Exit(2)
+	}
 
-	connectionString, err := config.GetConnectionStringByID(idDB)
+	if err := config.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "tel: config.Init failed: %v\n", err)
+		os.Exit(1)
+	}
+	counts, err := config.MergeFrom(*from, *overwrite)
+	config.Close()
 	if err != nil {
-		log.Printf("ERROR: config.GetConnectionStringByID failed for idDB=%d: %v", idDB, err)
+		fmt.Fprintf(os.Stderr, "tel: %v\n", err)
 		os.Exit(1)
 	}
-	log.Printf("connectionString: %s", connectionString)
+	printMergeCounts(counts)
+}
+
+func printMergeCounts(counts map[string]config.MergeCount) {
+	for _, table := range config.MergeTables {
+		c := counts[table]
+		fmt.Printf("%-8s imported %d, replaced %d, skipped %d\n", table, c.Imported, c.Replaced, c.Skipped)
+	}
+}
+
+func runConfigExport(arguments []string) {
+	fs := flag.NewFlagSet("config export-queries", flag.ExitOnError)
+	dbName := fs.String("db", "", "Only export the queries of this database (default: all)")
+	outputPath := fs.String("o", "-", "SQL file to write, or - for stdout")
+	fs.Parse(arguments)
 
-	sqlQuery, err := config.GetQueryFromDB(*sqlName)
+	if err := config.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "tel: config.Init failed: %v\n", err)
+		os.Exit(1)
+	}
+	err := config.ExportQueries(*dbName, *outputPath)
+	config.Close()
 	if err != nil {
-		log.Printf("ERROR: config.GetQueryFromDB failed for sqlName=%s: %v", *sqlName, err)
+		fmt.Fprintf(os.Stderr, "tel: %v\n", err)
 		os.Exit(1)
 	}
-	log.Printf("sqlQuery: %s", sqlQuery)
+}
 
-	if *args != "" {
-		file, err := os.Open(*args)
-		if err != nil {
-			log.Printf("ERROR: can't read file args: %s: %v", *args, err)
-			os.Exit(1)
-		}
-		defer file.Close()
-
-		var data map[string]interface{}
-		json.NewDecoder(file).Decode(&data)
-		// json.Unmarshal([]byte(jsonStr), &data)
-		for k, v := range data {
-			valueStr := fmt.Sprintf("%v", v)
-			sqlQuery = strings.ReplaceAll(sqlQuery, fmt.Sprintf(":%s", k), valueStr)
-		}
-		log.Println(sqlQuery)
+func runConfigImport(arguments []string) {
+	fs := flag.NewFlagSet("config import-queries", flag.ExitOnError)
+	overwrite := fs.Bool("overwrite", false, "Replace rows whose names already exist instead of skipping them")
+	fs.Parse(arguments)
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, configUsage)
+		os.Exit(2)
 	}
 
-	widths, aliases, tblHeight, err := config.GetQueryConfig(*sqlName)
+	if err := config.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "tel: config.Init failed: %v\n", err)
+		os.Exit(1)
+	}
+	counts, err := config.ImportQueries(fs.Arg(0), *overwrite, func(driver string) bool {
+		return slices.Contains(db.Drivers(), db.NormalizeDriver(driver))
+	})
+	config.Close()
 	if err != nil {
-		log.Printf("ERROR: config.GetQueryConfig failed for sqlName=%s: %v", *sqlName, err)
+		fmt.Fprintf(os.Stderr, "tel: %v\n", err)
 		os.Exit(1)
 	}
-	log.Printf("widths: %v, aliases: %v, tblHeight: %d", widths, aliases, tblHeight)
+	printMergeCounts(counts)
+}
 
-	view := *viewFlag
-	if view == "" {
-		view, err = config.GetQueryView(*sqlName)
-		if err != nil {
-			log.Printf("ERROR: config.GetQueryView failed for sqlName=%s: %v", *sqlName, err)
-			os.Exit(1)
-		}
+// runProfile implements the `tel profile add` and `list` subcommands.
+func runProfile(arguments []string) {
+	if len(arguments) == 0 {
+		fmt.Fprintln(os.Stderr, profileUsage)
+		os.Exit(2)
 	}
-	log.Printf("view: %s", view)
+	switch arguments[0] {
+	case "add":
+		runProfileAdd(arguments[1:])
+	case "list":
+		runProfileList()
+	default:
+		fmt.Fprintln(os.Stderr, profileUsage)
+		os.Exit(2)
+	}
+}
 
-	if err := db.Connect(driver, connectionString); err != nil {
-		log.Printf("ERROR: database.Connect failed for driver=%s: %v", driver, err)
-		os.Exit(1)
+const profileUsage = `usage: tel profile add [-db <name>] [-item <name>] [-sql <name>] [-args <file.json>] <profile>
+       tel profile list`
+
+func runProfileAdd(arguments []string) {
+	fs := flag.NewFlagSet("profile add", flag.ExitOnError)
+	dbName := fs.String("db", "", "Database name in dbs table")
+	itemName := fs.String("item", "", "Item name for config")
+	sqlName := fs.String("sql", "", "SQL query name in queries table, or @file.sql")
+	args := fs.String("args", "", "JSON with placeholder args in SQL query")
+	fs.Parse(arguments)
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, profileUsage)
+		os.Exit(2)
 	}
-	log.Println("Database connected successfully")
-	defer db.Close()
+	p := config.Profile{Name: fs.Arg(0), DB: *dbName, Item: *itemName, SQL: *sqlName, Args: *args}
 
-	rows, columns, err := db.GetContent(sqlQuery)
+	if err := config.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "tel: config.Init failed: %v\n", err)
+		os.Exit(1)
+	}
+	err := config.SaveProfile(p)
+	config.Close()
 	if err != nil {
-		log.Printf("ERROR: database.GetContent failed: %v", err)
+		fmt.Fprintf(os.Stderr, "tel: %v\n", err)
 		os.Exit(1)
 	}
-	log.Printf("Retrieved %d rows, %d columns", len(rows), len(columns))
+	fmt.Printf("saved profile %s\n", p.Name)
+}
 
-	if len(rows) == 0 || len(columns) == 0 {
-		log.Println("ERROR: No rows or columns retrieved from database")
+func runProfileList() {
+	if err := config.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "tel: config.Init failed: %v\n", err)
 		os.Exit(1)
 	}
-
-	columns = applyColumnWidths(columns, widths, aliases)
-	log.Printf("Applied column widths: %d columns processed", len(columns))
-
-	if tblHeight == 0 {
-		tblHeight = 10
-		log.Println("tblHeight was 0, set to default 10")
-	}
-
-	if len(rows) < 10 {
-		tblHeight = len(rows)
-		log.Printf("tblHeight adjusted to %d (rows count)", tblHeight)
-	}
-
-	tblHeight = tblHeight + 1
-	log.Printf("Final tblHeight: %d", tblHeight)
-
-	t := table.New(
-		table.WithColumns(columns),
-		table.WithRows(rows),
-		table.WithFocused(true),
-		table.WithHeight(tblHeight),
-	)
-
-	s := table.DefaultStyles()
-	s.Header = s.Header.
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color("240")).
-		BorderBottom(true).
-		Bold(false)
-	s.Selected = s.Selected.
-		Foreground(lipgloss.Color("229")).
-		Background(lipgloss.Color("57")).
-		Bold(false)
-	t.SetStyles(s)
-
-	ti := textinput.New()
-	ti.CharLimit = 500
-	ti.Width = 1000
-
-	// Load filter from instance table if uid is provided and filter flag is empty
-	if *filter == "" && *uid != "" {
-		loadedFilter, err := config.GetFilterByUID(*uid, idQuery)
-		if err != nil {
-			log.Printf("WARN: GetFilterByUID failed for uid=%s, idQuery=%d: %v", *uid, idQuery, err)
-		} else if loadedFilter != "" {
-			*filter = loadedFilter
-			log.Printf("Filter loaded from instance: %q", *filter)
-		}
+	profiles, err := config.ListProfiles()
+	config.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tel: %v\n", err)
+		os.Exit(1)
 	}
+	for _, p := range profiles {
+		fmt.Printf("%-12s db=%s item=%s sql=%s args=%s\n", p.Name, p.DB, p.Item, p.SQL, p.Args)
+	}
+}
 
-	if *filter != "" {
-		ti.SetValue(*filter)
-		log.Printf("Initial filter applied: %q", *filter)
+// runReport implements the `tel report add`, `list` and `run` subcommands.
+func runReport(arguments []string) {
+	if len(arguments) == 0 {
+		fmt.Fprintln(os.Stderr, reportUsage)
+		os.Exit(2)
+	}
+	switch arguments[0] {
+	case "add":
+		runReportAdd(arguments[1:])
+	case "list":
+		runReportList()
+	case "run":
+		runReportRun(arguments[1:])
+	default:
+		fmt.Fprintln(os.Stderr, reportUsage)
+		os.Exit(2)
 	}
+}
 
-	m := NewModel(t, ti, *itemName, *sqlName, sqlQuery, idDB, idQuery, tblHeight, aliases, *filter, *uid, view)
-	log.Printf("UI Model created: itemName=%s, sqlName=%s, idDB=%d, idQuery=%d, tblHeight=%d, uid=%s, view=%s",
-		*itemName, *sqlName, idDB, idQuery, tblHeight, *uid, view)
+const reportUsage = `usage: tel report add -db <name> <report> <query>...
+       tel report list
+       tel report run [-args <file.json>] [-output csv|tsv] [-no-header] <report>`
+
+func runReportAdd(arguments []string) {
+	fs := flag.NewFlagSet("report add", flag.ExitOnError)
+	dbName := fs.String("db", "", "Database name in dbs table the queries run on")
+	fs.Parse(arguments)
+	if *dbName == "" || fs.NArg() < 2 {
+		fmt.Fprintln(os.Stderr, reportUsage)
+		os.Exit(2)
+	}
+	r := config.Report{Name: fs.Arg(0), DB: *dbName, Queries: fs.Args()[1:]}
 
-	if *filter != "" {
-		rows, cols, err := m.FilterContent(*filter)
-		if err == nil && len(rows) > 0 {
-			t.SetRows(rows)
-			t.SetColumns(cols)
-			m.SetTable(t)
-			log.Printf("Filter applied: %d rows after filtering", len(rows))
-		}
-	} else if view == "c" {
-		// Apply vertical view for column mode without filter
-		rows, cols := ToVerticalView(rows, columns)
-		t.SetRows(rows)
-		t.SetColumns(cols)
-		m.SetTable(t)
-		log.Printf("Vertical view applied: %d rows", len(rows))
-	}
-
-	// Select row by hash if uid flag is provided
-	if *uid != "" {
-		hash, err := config.GetHashByUID(*uid, idQuery)
-		if err != nil {
-			log.Printf("WARN: GetHashByUID failed for uid=%s, idQuery=%d: %v", *uid, idQuery, err)
-		} else {
-			log.Printf("Looking for row with hash=%s", hash)
-			m.SelectRowByHash(hash)
-		}
+	if err := config.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "tel: config.Init failed: %v\n", err)
+		os.Exit(1)
+	}
+	err := config.SaveReport(r)
+	config.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tel: %v\n", err)
+		os.Exit(1)
 	}
+	fmt.Printf("saved report %s\n", r.Name)
+}
 
-	if _, err := tea.NewProgram(m).Run(); err != nil {
-		log.Printf("ERROR: tea.NewProgram.Run failed: %v", err)
-		fmt.Println("Error running program:", err)
+func runReportList() {
+	if err := config.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "tel: config.Init failed: %v\n", err)
+		os.Exit(1)
+	}
+	reports, err := config.ListReports()
+	config.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tel: %v\n", err)
 		os.Exit(1)
 	}
+	for _, r := range reports {
+		fmt.Printf("%-12s db=%s queries=%s\n", r.Name, r.DB, strings.Join(r.Queries, ","))
+	}
+}
 
-	log.Println("=== Application exited normally ===")
+func runReportRun(arguments []string) {
+	fs := flag.NewFlagSet("report run", flag.ExitOnError)
+	args := fs.String("args", "", "JSON with placeholder args bound into every query")
+	output := fs.String("output", "csv", "Format of each result: 'csv' or 'tsv'")
+	noHeader := fs.Bool("no-header", false, "Omit the column title rows")
+	logFileFlag := fs.String("log-file", "", "Log file path (default $TEL_LOG_FILE or logs/tel.log)")
+	fs.Parse(arguments)
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, reportUsage)
+		os.Exit(2)
+	}
+	if *output != "csv" && *output != "tsv" {
+		fmt.Fprintf(os.Stderr, "tel: unknown -output %q; use csv or tsv\n", *output)
+		os.Exit(2)
+	}
+
+	logFile := openLog(*logFileFlag)
+	defer logFile.Close()
+	log.SetOutput(logFile)
+	log.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds | log.Lshortfile)
+	log.Printf("report: name=%q, args=%q", fs.Arg(0), *args)
+
+	err := tel.RunReport(tel.ReportOptions{Name: fs.Arg(0), Args: *args, Output: *output, NoHeader: *noHeader})
+	if err != nil {
+		log.Printf("ERROR: %v", err)
+		fmt.Fprintf(os.Stderr, "tel: %v\n", err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestParseDelimiter(t *testing.T) {
+	tests := []struct {
+		in   string
+		want rune
+		ok   bool
+	}{
+		{"", 0, true},
+		{"|", '|', true},
+		{";", ';', true},
+		{"\t", '\t', true},
+		{"§", '§', true},
+		{"||", 0, false},
+		{`"`, 0, false},
+		{"\r", 0, false},
+		{"\n", 0, false},
+		// A lone byte >= 0x80 isn't a character.
+		{"\xa7", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := parseDelimiter(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parseDelimiter(%q) = %q, %v; want %q, ok %v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
@@ -1,28 +1,80 @@
 package config
 
 import (
-	"database/sql"
-	"encoding/json"
-	"fmt"
+	"io"
+	"log"
 	"os"
 	"os/user"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/charmbracelet/bubbles/table"
 
-	_ "modernc.org/sqlite"
+	"mcold/tel/internal/format"
 )
 
-var sqliteDB *sql.DB
+// DBEntry is a row of the dbs table.
+type DBEntry struct {
+	ID      int
+	Name    string
+	Driver  string
+	Connect string
+}
+
+// supportedDrivers are the driver names dbs may hold: those tel can be
+// built with and the aliases the db package resolves.
+var supportedDrivers = []string{
+	"pgx", "postgres", "postgresql", "pg",
+	"sqlite", "sqlite3",
+	"duckdb", "duck",
+	"mysql", "mssql",
+}
 
 type QueryConfig struct {
-	Widths  map[string]int    `json:"widths"`
-	Aliases map[string]string `json:"aliases"`
-	Height  int               `json:"height"`
+	Widths         map[string]int              `json:"widths"`
+	Aliases        map[string]string           `json:"aliases"`
+	Height         int                         `json:"height"`
+	BinaryEncoding string                      `json:"binary_encoding"`
+	TimeFormat     string                      `json:"time_format"`
+	BoolFormat     string                      `json:"bool_format"`
+	Formatters     map[string]format.Formatter `json:"formatters"`
+	Format         map[string]string           `json:"format"`
+	Keys           []string                    `json:"keys"`
+	Order          []string                    `json:"order"`
+	SortBy         SortBy                      `json:"sort_by"`
+	FrozenCols     []string                    `json:"frozen_cols"`
+	MaxWidth       int                         `json:"max_width"`
+	NullText       string                      `json:"null_text"`
+	EmptyText      string                      `json:"empty_text"`
 }
 
-func GetDBPath() (string, error) {
+// LastRun is the last TUI invocation, for tel last to repeat.
+type LastRun struct {
+	DB, Item, SQL, Query, Filter, UID string
+}
+
+// SortBy is the column rows are sorted by before display; Direction is
+// "asc" (the default) or "desc".
+type SortBy struct {
+	Column    string `json:"column"`
+	Direction string `json:"direction"`
+}
+
+var dir string
+
+// SetDir overrides the ~/.tel directory used for tel.db and theme files.
+func SetDir(path string) {
+	dir = path
+}
+
+// GetDir returns the ~/.tel directory, or the one set by SetDir, creating
+// it if needed.
+func GetDir() (string, error) {
+	if dir != "" {
+		return dir, os.MkdirAll(dir, 0755)
+	}
 	usr, err := user.Current()
 	if err != nil {
 		return "", err
@@ -31,345 +83,345 @@ func GetDBPath() (string, error) {
 	if err := os.MkdirAll(telDir, 0755); err != nil {
 		return "", err
 	}
+	return telDir, nil
+}
+
+func GetDBPath() (string, error) {
+	telDir, err := GetDir()
+	if err != nil {
+		return "", err
+	}
 	return filepath.Join(telDir, "tel.db"), nil
 }
 
+// ConfigStore persists tel's configuration: connections, items, saved
+// queries, per-user config values, instances and snapshots.
+type ConfigStore interface {
+	GetConnectionString(dbName string) (string, error)
+	GetDBID(dbName string) (int, error)
+	GetDBDriver(dbName string) (string, error)
+	GetDBDriverByID(idDB int) (string, error)
+	GetDBByDriver(driver string) ([]DBEntry, error)
+	GetDBDrivers() ([]string, error)
+	GetDBInitSQL(idDB int) (string, error)
+	GetQueryFromDB(sqlName string) (string, error)
+	GetQueryID(sqlName string) (int, error)
+	GetQueryView(sqlName string) (string, error)
+	GetItemID(itemName string) (int, error)
+	GetDBIDFromItem(itemID int) (int, error)
+	GetConnectionStringByID(idDB int) (string, error)
+	GetConnectionStringByItem(itemName string) (string, error)
+	GetQueryConfig(sqlName string) (map[string]int, map[string]string, int, error)
+	LoadQueryConfig(sqlName string) (QueryConfig, error)
+	SetQueryConfigValue(sqlName, key string, value interface{}) error
+	CloneQuery(srcName, dstName, dbName string) error
+	MergeFrom(srcDBPath string, overwrite bool) (map[string]MergeCount, error)
+	ExportQueries(dbName string, w io.Writer) error
+	ImportQueries(filePath string, overwrite bool, validDriver func(driver string) bool) (map[string]MergeCount, error)
+	InsertItemIfNotExists(item string, idDB int) error
+	InsertConfig(idItem int, uid string, row []string, cols []string, aliases map[string]string) error
+	SaveToConfig(itemName string, idDB int, uid string, row []string, cols []string, aliases map[string]string) error
+	SaveConfigFromTable(itemName string, idDB int, uid string, row []string, cols []table.Column, aliases map[string]string) error
+	TakeConfigSnapshot(itemName, uid string) (ConfigSnapshot, error)
+	RestoreConfigSnapshot(snap ConfigSnapshot) error
+	SaveInstance(idQuery int, hash string, providedUID string, filter string) (string, error)
+	GetHashByUID(uid string, idQuery int) (string, error)
+	GetFilterByUID(uid string, idQuery int) (string, error)
+	GetQueryIDByHash(hash string) (int, error)
+	SaveLastRun(run LastRun) error
+	GetLastRun() (LastRun, error)
+	SaveProfile(p Profile) error
+	GetProfile(name string) (Profile, error)
+	ListProfiles() ([]Profile, error)
+	SaveReport(r Report) error
+	GetReport(name string) (Report, error)
+	ListReports() ([]Report, error)
+	SaveSnapshot(sqlName string, idQuery int, uid string, cols []string, rows [][]string) (string, error)
+	LatestSnapshot(idQuery int, uid string) (string, error)
+	LoadSnapshot(name string) ([]string, [][]string, error)
+	Close() error
+}
+
+// Store backs the package-level functions. Init sets it to the SQLite store
+// in ~/.tel/tel.db; tests can swap in NewMemoryStore.
+var Store ConfigStore
+
 func Init() error {
 	dbPath, err := GetDBPath()
 	if err != nil {
 		return err
 	}
 
-	sqliteDB, err = sql.Open("sqlite", dbPath)
+	store, err := NewSQLiteStore(dbPath)
 	if err != nil {
 		return err
 	}
-
-	ddl := `
-	CREATE TABLE IF NOT EXISTS dbs(
-		id      INTEGER PRIMARY KEY AUTOINCREMENT
-		, driver STRING NOT NULL
-		, name	STRING UNIQUE
-		, connect TEXT
-		, comment TEXT
-	);
-
-	CREATE TABLE IF NOT EXISTS items(
-		id      INTEGER PRIMARY KEY AUTOINCREMENT
-		, id_db	INTEGER
-		, name  TEXT
-		, FOREIGN KEY (id_db) REFERENCES dbs(id)
-	);
-
-	CREATE TABLE IF NOT EXISTS config
-	(
-		id_item INTEGER
-		, uid TEXT
-		, var STRING
-		, val TEXT
-		, PRIMARY KEY (id_item, uid, var)
-		, FOREIGN KEY (id_item) REFERENCES items(id)
-	);
-
-	CREATE TABLE IF NOT EXISTS queries
-	(
-		id INTEGER
-		, id_item INTEGER
-		, name STRING UNIQUE
-		, query TEXT
-		, config TEXT
-		, height INTEGER DEFAULT 10
-		, view CHAR(1) DEFAULT 'r'
-		, PRIMARY KEY (id)
-		, FOREIGN KEY (id_item) REFERENCES items(id)
-	);
-
-	CREATE TABLE instance(
-		uid TEXT
-		, id_query INTEGER
-		, hash CHAR(64)
-		, filter TEXT
-		, PRIMARY KEY(uid, id_query)
-		, FOREIGN KEY (id_query) REFERENCES queries(id)
-	);
-	
-	
-	CREATE TRIGGER generate_uuid_trigger
-	AFTER INSERT ON instance
-	FOR EACH ROW
-	WHEN NEW.uid IS NULL
-	BEGIN
-		UPDATE instance SET uid = (
-			SELECT LOWER(
-				SUBSTR(hex, 1, 8) || '-' ||
-				SUBSTR(hex, 9, 4) || '-' ||
-				SUBSTR(hex, 13, 4) || '-' ||
-				SUBSTR(hex, 17, 4) || '-' ||
-				SUBSTR(hex, 21, 12)
-			)
-			FROM (SELECT HEX(RANDOMBLOB(16)) AS hex)
-		)
-		WHERE rowid = NEW.rowid;
-	END;
-	`
-
-	_, _ = sqliteDB.Exec(ddl)
+	if Store != nil {
+		Store.Close()
+	}
+	Store = store
+	InvalidateCache("")
+	checkDrivers()
 	return nil
 }
 
-func GetConnectionString(dbName string) (string, error) {
-	var connect string
-	err := sqliteDB.QueryRow("SELECT connect FROM dbs WHERE name = ?", dbName).Scan(&connect)
+// checkDrivers logs the dbs entries whose driver tel doesn't support.
+// They only fail when connected to, so the other entries keep working.
+func checkDrivers() {
+	drivers, err := Store.GetDBDrivers()
 	if err != nil {
-		return "", err
+		log.Printf("WARN: listing the drivers in dbs failed: %v", err)
+		return
+	}
+	for _, driver := range drivers {
+		if slices.Contains(supportedDrivers, strings.ToLower(driver)) {
+			continue
+		}
+		entries, err := Store.GetDBByDriver(driver)
+		if err != nil {
+			log.Printf("WARN: GetDBByDriver failed for driver=%s: %v", driver, err)
+			continue
+		}
+		for _, e := range entries {
+			log.Printf("WARN: db %s uses unsupported driver %q; use one of %s", e.Name, driver, strings.Join(supportedDrivers, ", "))
+		}
 	}
-	return connect, nil
 }
 
-func GetDBID(dbName string) (int, error) {
-	var id int
-	err := sqliteDB.QueryRow("SELECT id FROM dbs WHERE name = ?", dbName).Scan(&id)
-	if err != nil {
-		return 0, err
+// Close closes the store opened by Init.
+func Close() error {
+	if Store == nil {
+		return nil
 	}
-	return id, nil
+	err := Store.Close()
+	Store = nil
+	InvalidateCache("")
+	return err
+}
+
+func GetConnectionString(dbName string) (string, error) {
+	return Store.GetConnectionString(dbName)
+}
+
+func GetDBID(dbName string) (int, error) {
+	return Store.GetDBID(dbName)
 }
 
 func GetDBDriver(dbName string) (string, error) {
-	var driver string
-	err := sqliteDB.QueryRow("SELECT driver FROM dbs WHERE name = ?", dbName).Scan(&driver)
-	if err != nil {
-		return "", err
-	}
-	return driver, nil
+	return Store.GetDBDriver(dbName)
 }
 
 func GetDBDriverByID(idDB int) (string, error) {
-	var driver string
-	err := sqliteDB.QueryRow("SELECT driver FROM dbs WHERE id = ?", idDB).Scan(&driver)
-	if err != nil {
-		return "", err
-	}
-	return driver, nil
+	return Store.GetDBDriverByID(idDB)
+}
+
+// GetDBByDriver returns the dbs entries using driver, for driver-specific
+// handling of every connection.
+func GetDBByDriver(driver string) ([]DBEntry, error) {
+	return Store.GetDBByDriver(driver)
+}
+
+func GetDBInitSQL(idDB int) (string, error) {
+	return Store.GetDBInitSQL(idDB)
 }
 
 func GetQueryFromDB(sqlName string) (string, error) {
-	var query string
-	err := sqliteDB.QueryRow("SELECT query FROM queries WHERE name = ?", sqlName).Scan(&query)
-	if err != nil {
-		return "", err
-	}
-	return query, nil
+	return Store.GetQueryFromDB(sqlName)
 }
 
 func GetQueryID(sqlName string) (int, error) {
-	var id int
-	err := sqliteDB.QueryRow("SELECT id FROM queries WHERE name = ?", sqlName).Scan(&id)
-	if err != nil {
-		return 0, err
-	}
-	return id, nil
+	return Store.GetQueryID(sqlName)
 }
 
 func GetQueryView(sqlName string) (string, error) {
-	var view string
-	err := sqliteDB.QueryRow("SELECT COALESCE(view, 'r') FROM queries WHERE name = ?", sqlName).Scan(&view)
-	if err != nil {
-		return "", err
-	}
-	return view, nil
+	return Store.GetQueryView(sqlName)
 }
 
 func GetItemID(itemName string) (int, error) {
-	var id int
-	err := sqliteDB.QueryRow("SELECT id FROM items WHERE name = ?", itemName).Scan(&id)
-	if err != nil {
-		return 0, err
-	}
-	return id, nil
+	return Store.GetItemID(itemName)
 }
 
 func GetDBIDFromItem(itemID int) (int, error) {
-	var idDB int
-	err := sqliteDB.QueryRow("SELECT id_db FROM items WHERE id = ?", itemID).Scan(&idDB)
-	if err != nil {
-		return 0, err
-	}
-	return idDB, nil
+	return Store.GetDBIDFromItem(itemID)
 }
 
 func GetConnectionStringByID(idDB int) (string, error) {
-	var connect string
-	err := sqliteDB.QueryRow("SELECT connect FROM dbs WHERE id = ?", idDB).Scan(&connect)
-	if err != nil {
-		return "", err
-	}
-	return connect, nil
+	return Store.GetConnectionStringByID(idDB)
 }
 
 func GetConnectionStringByItem(itemName string) (string, error) {
-	itemID, err := GetItemID(itemName)
-	if err != nil {
-		return "", err
-	}
-	idDB, err := GetDBIDFromItem(itemID)
-	if err != nil {
-		return "", err
+	return Store.GetConnectionStringByItem(itemName)
+}
+
+// queryConfigs caches LoadQueryConfig by query name, as every filter run
+// reads the config again. Its maps are shared, so callers mustn't modify
+// them. Writes to queries must call InvalidateCache.
+var queryConfigs sync.Map
+
+// InvalidateCache drops the cached config of query name, or of all
+// queries when name is "".
+func InvalidateCache(name string) {
+	if name == "" {
+		queryConfigs.Clear()
+		return
 	}
-	return GetConnectionStringByID(idDB)
+	queryConfigs.Delete(name)
 }
 
 func GetQueryConfig(sqlName string) (map[string]int, map[string]string, int, error) {
-	var configJSON sql.NullString
-	var tableHeight int
-	err := sqliteDB.QueryRow("SELECT config, COALESCE(height, 10) FROM queries WHERE name = ?", sqlName).Scan(&configJSON, &tableHeight)
+	config, err := LoadQueryConfig(sqlName)
 	if err != nil {
 		return nil, nil, 0, err
 	}
+	return config.Widths, config.Aliases, config.Height, nil
+}
 
-	if !configJSON.Valid || configJSON.String == "" {
-		return make(map[string]int), make(map[string]string), tableHeight, nil
+func LoadQueryConfig(sqlName string) (QueryConfig, error) {
+	if config, ok := queryConfigs.Load(sqlName); ok {
+		return config.(QueryConfig), nil
 	}
-
-	var config QueryConfig
-	err = json.Unmarshal([]byte(configJSON.String), &config)
+	config, err := Store.LoadQueryConfig(sqlName)
 	if err != nil {
-		return nil, nil, 0, err
+		return config, err
 	}
+	queryConfigs.Store(sqlName, config)
+	return config, nil
+}
 
-	if config.Height == 0 {
-		config.Height = tableHeight
-	}
+func SetQueryConfigValue(sqlName, key string, value interface{}) error {
+	defer InvalidateCache(sqlName)
+	return Store.SetQueryConfigValue(sqlName, key, value)
+}
 
-	return config.Widths, config.Aliases, config.Height, nil
+// CloneQuery copies query srcName to a new query dstName in the same item.
+func CloneQuery(srcName, dstName string) error {
+	defer InvalidateCache(dstName)
+	return Store.CloneQuery(srcName, dstName, "")
 }
 
-func InsertItemIfNotExists(item string, idDB int) error {
-	var count int
-	err := sqliteDB.QueryRow("SELECT COUNT(*) FROM items WHERE name = ?", item).Scan(&count)
+// CloneQueryToDB copies query srcName to a new query dstName belonging to
+// database dbName.
+func CloneQueryToDB(srcName, dstName, dbName string) error {
+	defer InvalidateCache(dstName)
+	return Store.CloneQuery(srcName, dstName, dbName)
+}
+
+// MergeFrom imports the dbs, items and queries of another tel.db, keyed by
+// MergeTables.
+func MergeFrom(srcDBPath string, overwrite bool) (map[string]MergeCount, error) {
+	defer InvalidateCache("")
+	return Store.MergeFrom(srcDBPath, overwrite)
+}
+
+// ExportQueries writes the export script for dbName to outputPath, or to
+// stdout when outputPath is "-".
+func ExportQueries(dbName, outputPath string) error {
+	if outputPath == "-" {
+		return Store.ExportQueries(dbName, os.Stdout)
+	}
+	f, err := os.Create(outputPath)
 	if err != nil {
 		return err
 	}
-	if count == 0 {
-		_, err = sqliteDB.Exec("INSERT INTO items (name, id_db) VALUES (?, ?)", item, idDB)
-		if err != nil {
-			return err
-		}
+	if err := Store.ExportQueries(dbName, f); err != nil {
+		f.Close()
+		return err
 	}
-	return nil
+	return f.Close()
+}
+
+// ImportQueries merges the dbs, items and queries of a script written by
+// ExportQueries, keyed by MergeTables.
+func ImportQueries(filePath string, overwrite bool, validDriver func(driver string) bool) (map[string]MergeCount, error) {
+	defer InvalidateCache("")
+	return Store.ImportQueries(filePath, overwrite, validDriver)
+}
+
+func InsertItemIfNotExists(item string, idDB int) error {
+	return Store.InsertItemIfNotExists(item, idDB)
 }
 
 func InsertConfig(idItem int, uid string, row []string, cols []string, aliases map[string]string) error {
-	for i := range cols {
-		if i < len(row) {
-			colTitle := strings.ToUpper(cols[i])
-			if _, ok := aliases[colTitle]; ok {
-				varValue := row[i]
-				_, err := sqliteDB.Exec(
-					"INSERT OR REPLACE INTO config (id_item, uid, var, val) VALUES (?, ?, ?, ?)",
-					idItem, uid, aliases[colTitle], varValue,
-				)
-				if err != nil {
-					return err
-				}
-			}
-		}
-	}
-	return nil
+	return Store.InsertConfig(idItem, uid, row, cols, aliases)
 }
 
 func SaveToConfig(itemName string, idDB int, uid string, row []string, cols []string, aliases map[string]string) error {
-	if err := InsertItemIfNotExists(itemName, idDB); err != nil {
-		return err
-	}
-	idItem, err := GetItemID(itemName)
-	if err != nil {
-		return err
-	}
-
-	return InsertConfig(idItem, uid, row, cols, aliases)
+	return Store.SaveToConfig(itemName, idDB, uid, row, cols, aliases)
 }
 
 func SaveConfigFromTable(itemName string, idDB int, uid string, row []string, cols []table.Column, aliases map[string]string) error {
-	if err := InsertItemIfNotExists(itemName, idDB); err != nil {
-		return err
-	}
-	idItem, err := GetItemID(itemName)
-	if err != nil {
-		return err
-	}
+	return Store.SaveConfigFromTable(itemName, idDB, uid, row, cols, aliases)
+}
 
-	for i := range cols {
-		if i < len(row) {
-			colTitle := strings.ToUpper(cols[i].Title)
-			if _, ok := aliases[colTitle]; ok {
-				varValue := row[i]
-				_, err := sqliteDB.Exec(
-					"INSERT OR REPLACE INTO config (id_item, uid, var, val) VALUES (?, ?, ?, ?)",
-					idItem, uid, aliases[colTitle], varValue,
-				)
-				if err != nil {
-					return err
-				}
-			}
-		}
-	}
-	return nil
+// TakeConfigSnapshot records the config saved for an item instance, to
+// undo a save with RestoreConfigSnapshot.
+func TakeConfigSnapshot(itemName, uid string) (ConfigSnapshot, error) {
+	return Store.TakeConfigSnapshot(itemName, uid)
+}
+
+// RestoreConfigSnapshot puts back the config recorded in snap.
+func RestoreConfigSnapshot(snap ConfigSnapshot) error {
+	return Store.RestoreConfigSnapshot(snap)
+}
+
+func SaveLastRun(run LastRun) error {
+	return Store.SaveLastRun(run)
+}
+
+func GetLastRun() (LastRun, error) {
+	return Store.GetLastRun()
+}
+
+func SaveProfile(p Profile) error {
+	return Store.SaveProfile(p)
+}
+
+// GetProfile returns the profile tel -profile name starts from.
+func GetProfile(name string) (Profile, error) {
+	return Store.GetProfile(name)
+}
+
+func ListProfiles() ([]Profile, error) {
+	return Store.ListProfiles()
+}
+
+func SaveReport(r Report) error {
+	return Store.SaveReport(r)
+}
+
+// GetReport returns the report tel report run name runs.
+func GetReport(name string) (Report, error) {
+	return Store.GetReport(name)
+}
+
+func ListReports() ([]Report, error) {
+	return Store.ListReports()
 }
 
 func SaveInstance(idQuery int, hash string, providedUID string, filter string) (string, error) {
-	uid := providedUID
-	if uid == "" {
-		var err error
-		uid, err = generateUUID()
-		if err != nil {
-			return "", err
-		}
-	}
-	_, err := sqliteDB.Exec(
-		"INSERT OR REPLACE INTO instance (uid, id_query, hash, filter) VALUES (?, ?, ?, ?)",
-		uid, idQuery, hash, filter,
-	)
-	if err != nil {
-		return "", err
-	}
-	return uid, nil
+	return Store.SaveInstance(idQuery, hash, providedUID, filter)
 }
 
 func GetHashByUID(uid string, idQuery int) (string, error) {
-	var hash string
-	err := sqliteDB.QueryRow("SELECT hash FROM instance WHERE uid = ? AND id_query = ?", uid, idQuery).Scan(&hash)
-	if err != nil {
-		return "", err
-	}
-	return hash, nil
+	return Store.GetHashByUID(uid, idQuery)
 }
 
 func GetFilterByUID(uid string, idQuery int) (string, error) {
-	var filter string
-	err := sqliteDB.QueryRow("SELECT COALESCE(filter, '') FROM instance WHERE uid = ? AND id_query = ?", uid, idQuery).Scan(&filter)
-	if err != nil {
-		return "", err
-	}
-	return filter, nil
+	return Store.GetFilterByUID(uid, idQuery)
 }
 
 func GetQueryIDByHash(hash string) (int, error) {
-	var idQuery int
-	err := sqliteDB.QueryRow("SELECT id_query FROM instance WHERE hash = ?", hash).Scan(&idQuery)
-	if err != nil {
-		return 0, err
-	}
-	return idQuery, nil
+	return Store.GetQueryIDByHash(hash)
 }
 
-func generateUUID() (string, error) {
-	var hex string
-	err := sqliteDB.QueryRow("SELECT lower(hex(randomblob(16)))").Scan(&hex)
-	if err != nil {
-		return "", err
-	}
-	uid := fmt.Sprintf("%s-%s-%s-%s-%s",
-		hex[0:8], hex[8:12], hex[12:16], hex[16:20], hex[20:32])
-	return uid, nil
+func SaveSnapshot(sqlName string, idQuery int, uid string, cols []string, rows [][]string) (string, error) {
+	return Store.SaveSnapshot(sqlName, idQuery, uid, cols, rows)
+}
+
+func LatestSnapshot(idQuery int, uid string) (string, error) {
+	return Store.LatestSnapshot(idQuery, uid)
+}
+
+func LoadSnapshot(name string) ([]string, [][]string, error) {
+	return Store.LoadSnapshot(name)
 }
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/table"
+)
+
+// initTemp opens a fresh tel.db in a temporary directory for the test.
+func initTemp(t *testing.T) {
+	t.Helper()
+	SetDir(t.TempDir())
+	if err := Init(); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	t.Cleanup(func() {
+		Close()
+		SetDir("")
+	})
+}
+
+func TestSaveConfigFromTableRoundTrip(t *testing.T) {
+	initTemp(t)
+
+	cols := []table.Column{{Title: "ID"}, {Title: "name"}, {Title: "STATUS"}}
+	// Aliases are keyed by upper-case column name; columns without one
+	// aren't saved.
+	aliases := map[string]string{"NAME": "user_name", "STATUS": "user_status"}
+
+	if err := SaveConfigFromTable("users", 1, "u1", []string{"1", "alice", "active"}, cols, aliases); err != nil {
+		t.Fatalf("SaveConfigFromTable u1: %v", err)
+	}
+	if err := SaveConfigFromTable("users", 1, "u2", []string{"2", "bob", "inactive"}, cols, aliases); err != nil {
+		t.Fatalf("SaveConfigFromTable u2: %v", err)
+	}
+	// Saving u1 again replaces its values rather than adding rows.
+	if err := SaveConfigFromTable("users", 1, "u1", []string{"3", "carol", "active"}, cols, aliases); err != nil {
+		t.Fatalf("SaveConfigFromTable u1 again: %v", err)
+	}
+
+	for uid, want := range map[string]map[string]string{
+		"u1": {"user_name": "carol", "user_status": "active"},
+		"u2": {"user_name": "bob", "user_status": "inactive"},
+	} {
+		snap, err := TakeConfigSnapshot("users", uid)
+		if err != nil {
+			t.Fatalf("TakeConfigSnapshot %s: %v", uid, err)
+		}
+		if !maps.Equal(snap.Values, want) {
+			t.Errorf("config of %s = %v, want %v", uid, snap.Values, want)
+		}
+	}
+
+	idItem, err := GetItemID("users")
+	if err != nil {
+		t.Fatalf("GetItemID: %v", err)
+	}
+	idDB, err := GetDBIDFromItem(idItem)
+	if err != nil || idDB != 1 {
+		t.Errorf("GetDBIDFromItem = %d, %v; want 1", idDB, err)
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	initTemp(t)
+
+	// Names that %q would have escaped the Go way, and a keyword.
+	cols := []string{`say "hi"`, "naïve", "select"}
+	rows := [][]string{{"a", "1", ""}, {`q"uote`, "2", "x"}}
+
+	first, err := SaveSnapshot("users", 1, "u1", cols, rows)
+	if err != nil {
+		t.Fatalf("SaveSnapshot: %v", err)
+	}
+	// A second snapshot within the same second gets its own table.
+	second, err := SaveSnapshot("users", 1, "u1", cols, rows[:1])
+	if err != nil {
+		t.Fatalf("SaveSnapshot again: %v", err)
+	}
+	if first == second {
+		t.Fatalf("both snapshots are named %s", first)
+	}
+
+	latest, err := LatestSnapshot(1, "u1")
+	if err != nil || latest != second {
+		t.Errorf("LatestSnapshot = %s, %v; want %s", latest, err, second)
+	}
+
+	gotCols, gotRows, err := LoadSnapshot(first)
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	if !slices.Equal(gotCols, cols) {
+		t.Errorf("columns = %q, want %q", gotCols, cols)
+	}
+	if !slices.EqualFunc(gotRows, rows, slices.Equal) {
+		t.Errorf("rows = %q, want %q", gotRows, rows)
+	}
+}
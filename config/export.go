@@ -0,0 +1,148 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// exportSteps select the rows ExportQueries writes, in dependency order. ?
+// is the database name, or NULL to export every database.
+var exportSteps = []struct{ table, where string }{
+	{"dbs", "? IS NULL OR name = ?"},
+	{"items", "id_db IN (SELECT id FROM dbs WHERE ? IS NULL OR name = ?)"},
+	{"queries", "id_item IN (SELECT i.id FROM items i JOIN dbs d ON d.id = i.id_db WHERE ? IS NULL OR d.name = ?)"},
+}
+
+// ExportQueries writes the queries of database dbName, or of every database
+// when it's empty, with their items and dbs as a SQL script. The script
+// creates the tables if needed, so it can seed a fresh tel.db.
+func (s *SQLiteStore) ExportQueries(dbName string, w io.Writer) error {
+	var name interface{}
+	if dbName != "" {
+		if _, err := s.GetDBID(dbName); err != nil {
+			return fmt.Errorf("database %s not found", dbName)
+		}
+		name = dbName
+	}
+
+	if _, err := fmt.Fprintln(w, "BEGIN TRANSACTION;"); err != nil {
+		return err
+	}
+	for _, step := range exportSteps {
+		// sqlite_master keeps the columns added by migrations.
+		var ddl string
+		if err := s.db.QueryRow("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", step.table).Scan(&ddl); err != nil {
+			return fmt.Errorf("can't read schema of %s: %w", step.table, err)
+		}
+		ddl = strings.Replace(ddl, "CREATE TABLE", "CREATE TABLE IF NOT EXISTS", 1)
+		if _, err := fmt.Fprintf(w, "\n%s;\n", ddl); err != nil {
+			return err
+		}
+
+		cols, err := s.tableColumns(step.table)
+		if err != nil {
+			return err
+		}
+		quoted := make([]string, len(cols))
+		for i, col := range cols {
+			quoted[i] = fmt.Sprintf("quote(%q)", col)
+		}
+		rows, err := s.db.Query(fmt.Sprintf(
+			"SELECT %s FROM %s WHERE %s ORDER BY id", strings.Join(quoted, " || ', ' || "), step.table, step.where,
+		), name, name)
+		if err != nil {
+			return fmt.Errorf("exporting %s failed: %w", step.table, err)
+		}
+		for rows.Next() {
+			var values string
+			if err := rows.Scan(&values); err != nil {
+				rows.Close()
+				return err
+			}
+			_, err := fmt.Fprintf(w, "INSERT OR REPLACE INTO %s (%s) VALUES (%s);\n", step.table, strings.Join(cols, ", "), values)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		if err := rows.Close(); err != nil {
+			return err
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w, "\nCOMMIT;")
+	return err
+}
+
+func (s *SQLiteStore) tableColumns(table string) ([]string, error) {
+	rows, err := s.db.Query("SELECT name FROM pragma_table_info(?) ORDER BY cid", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var cols []string
+	for rows.Next() {
+		var col string
+		if err := rows.Scan(&col); err != nil {
+			return nil, err
+		}
+		cols = append(cols, col)
+	}
+	return cols, rows.Err()
+}
+
+// ImportQueries loads a script written by ExportQueries into a scratch
+// database, then merges its dbs, items and queries into this one by name
+// as MergeFrom does. Nothing is imported if a db's driver fails
+// validDriver.
+func (s *SQLiteStore) ImportQueries(filePath string, overwrite bool, validDriver func(driver string) bool) (map[string]MergeCount, error) {
+	script, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Run the script on its own file so it can't touch anything else in
+	// tel.db.
+	scratch, err := os.CreateTemp("", "tel-import-*.db")
+	if err != nil {
+		return nil, err
+	}
+	scratch.Close()
+	defer os.Remove(scratch.Name())
+
+	scratchDB, err := sql.Open("sqlite", scratch.Name())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := scratchDB.Exec(string(script)); err != nil {
+		scratchDB.Close()
+		return nil, fmt.Errorf("can't load %s: %w", filePath, err)
+	}
+	if err := scratchDB.Close(); err != nil {
+		return nil, err
+	}
+
+	return s.merge(scratch.Name(), overwrite, func(ctx context.Context, tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, "SELECT COALESCE(name, ''), driver FROM src.dbs")
+		if err != nil {
+			return fmt.Errorf("%s has no dbs: %w", filePath, err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var name, driver string
+			if err := rows.Scan(&name, &driver); err != nil {
+				return err
+			}
+			if validDriver != nil && !validDriver(driver) {
+				return fmt.Errorf("db %s uses unknown driver %q", name, driver)
+			}
+		}
+		return rows.Err()
+	})
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// MergeCount is how many rows of one table MergeFrom imported, replaced and
+// skipped because the name already existed.
+type MergeCount struct {
+	Imported int
+	Replaced int
+	Skipped  int
+}
+
+// MergeTables lists the tables MergeFrom copies, in the order it copies them.
+var MergeTables = []string{"dbs", "items", "queries"}
+
+// mergeSteps copy rows from the attached src database by name. Items and
+// queries get their id_db and id_item remapped through the names of the
+// rows they reference. Each step's upsert replaces rows with existing names;
+// insert only adds new ones. $INIT_SQL stands for src.dbs.init_sql, or NULL
+// when the source predates that column.
+var mergeSteps = map[string]struct{ insert, upsert string }{
+	"dbs": {
+		insert: `INSERT INTO main.dbs (driver, name, connect, comment, init_sql)
+			SELECT driver, name, connect, comment, $INIT_SQL FROM src.dbs
+			WHERE name NOT IN (SELECT name FROM main.dbs WHERE name IS NOT NULL)`,
+		upsert: `INSERT INTO main.dbs (driver, name, connect, comment, init_sql)
+			SELECT driver, name, connect, comment, $INIT_SQL FROM src.dbs WHERE true
+			ON CONFLICT(name) DO UPDATE SET driver = excluded.driver, connect = excluded.connect,
+				comment = excluded.comment, init_sql = excluded.init_sql`,
+	},
+	"items": {
+		insert: `INSERT INTO main.items (id_db, name)
+			SELECT (SELECT m.id FROM main.dbs m JOIN src.dbs s ON s.name = m.name WHERE s.id = i.id_db), i.name
+			FROM src.items i
+			WHERE i.name NOT IN (SELECT name FROM main.items WHERE name IS NOT NULL)`,
+		upsert: `UPDATE main.items SET id_db = (
+				SELECT m.id FROM src.items i JOIN src.dbs s ON s.id = i.id_db JOIN main.dbs m ON m.name = s.name
+				WHERE i.name = main.items.name)
+			WHERE name IN (SELECT name FROM src.items)`,
+	},
+	"queries": {
+		insert: `INSERT INTO main.queries (id_item, name, query, config, height, view)
+			SELECT (SELECT MIN(m.id) FROM main.items m JOIN src.items s ON s.name = m.name WHERE s.id = q.id_item),
+				q.name, q.query, q.config, q.height, q.view
+			FROM src.queries q
+			WHERE q.name NOT IN (SELECT name FROM main.queries WHERE name IS NOT NULL)`,
+		upsert: `INSERT INTO main.queries (id_item, name, query, config, height, view)
+			SELECT (SELECT MIN(m.id) FROM main.items m JOIN src.items s ON s.name = m.name WHERE s.id = q.id_item),
+				q.name, q.query, q.config, q.height, q.view
+			FROM src.queries q WHERE true
+			ON CONFLICT(name) DO UPDATE SET id_item = excluded.id_item, query = excluded.query,
+				config = excluded.config, height = excluded.height, view = excluded.view`,
+	},
+}
+
+// MergeFrom copies the dbs, items and queries of the tel.db at srcDBPath
+// whose names aren't in this one. With overwrite, rows with existing names
+// are replaced instead of skipped.
+func (s *SQLiteStore) MergeFrom(srcDBPath string, overwrite bool) (map[string]MergeCount, error) {
+	return s.merge(srcDBPath, overwrite, nil)
+}
+
+// merge does MergeFrom, first running check, if set, in the transaction
+// with src attached.
+func (s *SQLiteStore) merge(srcDBPath string, overwrite bool, check func(ctx context.Context, tx *sql.Tx) error) (map[string]MergeCount, error) {
+	ctx := context.Background()
+	// ATTACH applies to one connection, so keep to a single one.
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS src", srcDBPath); err != nil {
+		return nil, fmt.Errorf("can't attach %s: %w", srcDBPath, err)
+	}
+	defer conn.ExecContext(ctx, "DETACH DATABASE src")
+
+	// Databases from before init_sql was added don't have the column.
+	initSQL := "NULL"
+	var hasInitSQL int
+	err = conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM pragma_table_info('dbs', 'src') WHERE name = 'init_sql'").Scan(&hasInitSQL)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a tel database: %w", srcDBPath, err)
+	}
+	if hasInitSQL > 0 {
+		initSQL = "init_sql"
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if check != nil {
+		if err := check(ctx, tx); err != nil {
+			return nil, err
+		}
+	}
+
+	counts := make(map[string]MergeCount, len(MergeTables))
+	for _, table := range MergeTables {
+		var total, existing int
+		err := tx.QueryRowContext(ctx, fmt.Sprintf(
+			"SELECT COUNT(*), COUNT(*) FILTER (WHERE name IN (SELECT name FROM main.%[1]s)) FROM src.%[1]s", table,
+		)).Scan(&total, &existing)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a tel database: %w", srcDBPath, err)
+		}
+
+		step := mergeSteps[table]
+		if _, err := tx.ExecContext(ctx, strings.ReplaceAll(step.insert, "$INIT_SQL", initSQL)); err != nil {
+			return nil, fmt.Errorf("merging %s failed: %w", table, err)
+		}
+		count := MergeCount{Imported: total - existing, Skipped: existing}
+		if overwrite && existing > 0 {
+			if _, err := tx.ExecContext(ctx, strings.ReplaceAll(step.upsert, "$INIT_SQL", initSQL)); err != nil {
+				return nil, fmt.Errorf("overwriting %s failed: %w", table, err)
+			}
+			count.Replaced, count.Skipped = existing, 0
+		}
+		counts[table] = count
+	}
+	return counts, tx.Commit()
+}
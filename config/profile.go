@@ -0,0 +1,47 @@
+package config
+
+// Profile bundles the flags a common tel invocation repeats under one
+// name, for tel -profile. Empty fields leave the flag to the command line.
+type Profile struct {
+	Name, DB, Item, SQL, Args string
+}
+
+// SaveProfile creates the profile p.Name, or replaces it.
+func (s *SQLiteStore) SaveProfile(p Profile) error {
+	_, err := s.db.Exec(
+		"INSERT OR REPLACE INTO profiles (name, db, item, sql, args) VALUES (?, ?, ?, ?, ?)",
+		p.Name, p.DB, p.Item, p.SQL, p.Args,
+	)
+	return err
+}
+
+// GetProfile returns the profile called name; sql.ErrNoRows when there is
+// none.
+func (s *SQLiteStore) GetProfile(name string) (Profile, error) {
+	p := Profile{Name: name}
+	err := s.db.QueryRow(`
+		SELECT COALESCE(db, ''), COALESCE(item, ''), COALESCE(sql, ''), COALESCE(args, '')
+		FROM profiles WHERE name = ?`, name,
+	).Scan(&p.DB, &p.Item, &p.SQL, &p.Args)
+	return p, err
+}
+
+// ListProfiles returns all profiles ordered by name.
+func (s *SQLiteStore) ListProfiles() ([]Profile, error) {
+	rows, err := s.db.Query(`
+		SELECT name, COALESCE(db, ''), COALESCE(item, ''), COALESCE(sql, ''), COALESCE(args, '')
+		FROM profiles ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var profiles []Profile
+	for rows.Next() {
+		var p Profile
+		if err := rows.Scan(&p.Name, &p.DB, &p.Item, &p.SQL, &p.Args); err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles, rows.Err()
+}
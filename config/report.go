@@ -0,0 +1,61 @@
+package config
+
+import "encoding/json"
+
+// Report is a list of saved queries run together against one database,
+// sharing the -args of tel report run.
+type Report struct {
+	Name, DB string
+	Queries  []string
+}
+
+// SaveReport creates the report r.Name, or replaces it. The query names
+// are stored as a JSON array.
+func (s *SQLiteStore) SaveReport(r Report) error {
+	queries, err := json.Marshal(r.Queries)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(
+		"INSERT OR REPLACE INTO reports (name, db, queries) VALUES (?, ?, ?)",
+		r.Name, r.DB, string(queries),
+	)
+	return err
+}
+
+// GetReport returns the report called name; sql.ErrNoRows when there is
+// none.
+func (s *SQLiteStore) GetReport(name string) (Report, error) {
+	r := Report{Name: name}
+	var queries string
+	err := s.db.QueryRow(
+		"SELECT COALESCE(db, ''), COALESCE(queries, '[]') FROM reports WHERE name = ?", name,
+	).Scan(&r.DB, &queries)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal([]byte(queries), &r.Queries)
+	return r, err
+}
+
+// ListReports returns all reports ordered by name.
+func (s *SQLiteStore) ListReports() ([]Report, error) {
+	rows, err := s.db.Query("SELECT name, COALESCE(db, ''), COALESCE(queries, '[]') FROM reports ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var reports []Report
+	for rows.Next() {
+		var r Report
+		var queries string
+		if err := rows.Scan(&r.Name, &r.DB, &queries); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(queries), &r.Queries); err != nil {
+			return nil, err
+		}
+		reports = append(reports, r)
+	}
+	return reports, rows.Err()
+}
@@ -0,0 +1,733 @@
+package config
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/table"
+
+	"mcold/tel/internal/sqlident"
+
+	_ "modernc.org/sqlite"
+)
+
+// ddl creates the tel tables. Statements run one by one, so a failing one
+// is reported rather than silently skipping those after it.
+var ddl = []string{
+	`CREATE TABLE IF NOT EXISTS dbs(
+		id      INTEGER PRIMARY KEY AUTOINCREMENT
+		, driver STRING NOT NULL
+		, name	STRING UNIQUE
+		, connect TEXT
+		, comment TEXT
+		, init_sql TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS items(
+		id      INTEGER PRIMARY KEY AUTOINCREMENT
+		, id_db	INTEGER
+		, name  TEXT
+		, FOREIGN KEY (id_db) REFERENCES dbs(id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS config
+	(
+		id_item INTEGER
+		, uid TEXT
+		, var STRING
+		, val TEXT
+		, PRIMARY KEY (id_item, uid, var)
+		, FOREIGN KEY (id_item) REFERENCES items(id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS queries
+	(
+		id INTEGER
+		, id_item INTEGER
+		, name STRING UNIQUE
+		, query TEXT
+		, config TEXT
+		, height INTEGER DEFAULT 10
+		, view CHAR(1) DEFAULT 'r'
+		, PRIMARY KEY (id)
+		, FOREIGN KEY (id_item) REFERENCES items(id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS snapshots
+	(
+		name TEXT PRIMARY KEY
+		, id_query INTEGER
+		, uid TEXT
+		, created TEXT
+		, FOREIGN KEY (id_query) REFERENCES queries(id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS instance(
+		uid TEXT
+		, id_query INTEGER
+		, hash CHAR(64)
+		, filter TEXT
+		, PRIMARY KEY(uid, id_query)
+		, FOREIGN KEY (id_query) REFERENCES queries(id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS state
+	(
+		id INTEGER PRIMARY KEY CHECK (id = 1)
+		, db TEXT
+		, item TEXT
+		, sql TEXT
+		, query TEXT
+		, filter TEXT
+		, uid TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS profiles
+	(
+		name TEXT PRIMARY KEY
+		, db TEXT
+		, item TEXT
+		, sql TEXT
+		, args TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS reports
+	(
+		name TEXT PRIMARY KEY
+		, db TEXT
+		, queries TEXT
+	)`,
+	`CREATE TRIGGER IF NOT EXISTS generate_uuid_trigger
+	AFTER INSERT ON instance
+	FOR EACH ROW
+	WHEN NEW.uid IS NULL
+	BEGIN
+		UPDATE instance SET uid = (
+			SELECT LOWER(
+				SUBSTR(hex, 1, 8) || '-' ||
+				SUBSTR(hex, 9, 4) || '-' ||
+				SUBSTR(hex, 13, 4) || '-' ||
+				SUBSTR(hex, 17, 4) || '-' ||
+				SUBSTR(hex, 21, 12)
+			)
+			FROM (SELECT HEX(RANDOMBLOB(16)) AS hex)
+		)
+		WHERE rowid = NEW.rowid;
+	END`,
+}
+
+// migrations add columns to tables created by older versions of tel. Each
+// runs on its own; "duplicate column" errors mean it was already applied.
+var migrations = []string{
+	`ALTER TABLE dbs ADD COLUMN init_sql TEXT`,
+}
+
+// createTables runs ddl, returning the errors other than "already exists".
+func createTables(sqliteDB *sql.DB) error {
+	for _, stmt := range ddl {
+		if _, err := sqliteDB.Exec(stmt); err != nil && !strings.Contains(err.Error(), "already exists") {
+			return fmt.Errorf("creating tables failed: %w", err)
+		}
+	}
+	return nil
+}
+
+func migrate(sqliteDB *sql.DB) error {
+	for _, stmt := range migrations {
+		if _, err := sqliteDB.Exec(stmt); err != nil && !strings.Contains(err.Error(), "duplicate column") {
+			return fmt.Errorf("migration %q failed: %w", stmt, err)
+		}
+	}
+	return nil
+}
+
+// SQLiteStore implements ConfigStore on a SQLite database.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens the SQLite database at path and creates the tel
+// tables if they don't exist yet.
+func NewSQLiteStore(path string) (ConfigStore, error) {
+	sqliteDB, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := createTables(sqliteDB); err != nil {
+		sqliteDB.Close()
+		return nil, err
+	}
+	if err := migrate(sqliteDB); err != nil {
+		sqliteDB.Close()
+		return nil, err
+	}
+	return &SQLiteStore{db: sqliteDB}, nil
+}
+
+// NewMemoryStore returns a store backed by a private in-memory database.
+func NewMemoryStore() (ConfigStore, error) {
+	sqliteDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		return nil, err
+	}
+	// Every connection to :memory: is a separate database.
+	sqliteDB.SetMaxOpenConns(1)
+
+	if err := createTables(sqliteDB); err != nil {
+		sqliteDB.Close()
+		return nil, err
+	}
+	if err := migrate(sqliteDB); err != nil {
+		sqliteDB.Close()
+		return nil, err
+	}
+	return &SQLiteStore{db: sqliteDB}, nil
+}
+
+// Close checkpoints the WAL, if the database uses one, so no -wal or -shm
+// files linger, then closes the database.
+func (s *SQLiteStore) Close() error {
+	var mode string
+	if err := s.db.QueryRow("PRAGMA journal_mode").Scan(&mode); err == nil && strings.EqualFold(mode, "wal") {
+		if _, err := s.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+			s.db.Close()
+			return fmt.Errorf("wal checkpoint failed: %w", err)
+		}
+	}
+	return s.db.Close()
+}
+
+func (s *SQLiteStore) GetConnectionString(dbName string) (string, error) {
+	var connect string
+	err := s.db.QueryRow("SELECT connect FROM dbs WHERE name = ?", dbName).Scan(&connect)
+	if err != nil {
+		return "", err
+	}
+	return connect, nil
+}
+
+func (s *SQLiteStore) GetDBID(dbName string) (int, error) {
+	var id int
+	err := s.db.QueryRow("SELECT id FROM dbs WHERE name = ?", dbName).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *SQLiteStore) GetDBDriver(dbName string) (string, error) {
+	var driver string
+	err := s.db.QueryRow("SELECT driver FROM dbs WHERE name = ?", dbName).Scan(&driver)
+	if err != nil {
+		return "", err
+	}
+	return driver, nil
+}
+
+func (s *SQLiteStore) GetDBDriverByID(idDB int) (string, error) {
+	var driver string
+	err := s.db.QueryRow("SELECT driver FROM dbs WHERE id = ?", idDB).Scan(&driver)
+	if err != nil {
+		return "", err
+	}
+	return driver, nil
+}
+
+// GetDBByDriver returns the dbs entries whose driver is exactly driver,
+// ordered by name.
+func (s *SQLiteStore) GetDBByDriver(driver string) ([]DBEntry, error) {
+	rows, err := s.db.Query(`
+		SELECT id, COALESCE(name, ''), driver, COALESCE(connect, '')
+		FROM dbs WHERE driver = ? ORDER BY name`, driver)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var entries []DBEntry
+	for rows.Next() {
+		var e DBEntry
+		if err := rows.Scan(&e.ID, &e.Name, &e.Driver, &e.Connect); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// GetDBDrivers returns the distinct driver names stored in dbs.
+func (s *SQLiteStore) GetDBDrivers() ([]string, error) {
+	rows, err := s.db.Query("SELECT DISTINCT driver FROM dbs ORDER BY driver")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var drivers []string
+	for rows.Next() {
+		var driver string
+		if err := rows.Scan(&driver); err != nil {
+			return nil, err
+		}
+		drivers = append(drivers, driver)
+	}
+	return drivers, rows.Err()
+}
+
+// GetDBInitSQL returns the SQL run after connecting to the database, such as
+// SET search_path, or "" when none is set.
+func (s *SQLiteStore) GetDBInitSQL(idDB int) (string, error) {
+	var initSQL sql.NullString
+	err := s.db.QueryRow("SELECT init_sql FROM dbs WHERE id = ?", idDB).Scan(&initSQL)
+	if err != nil {
+		return "", err
+	}
+	return initSQL.String, nil
+}
+
+func (s *SQLiteStore) GetQueryFromDB(sqlName string) (string, error) {
+	var query string
+	err := s.db.QueryRow("SELECT query FROM queries WHERE name = ?", sqlName).Scan(&query)
+	if err != nil {
+		return "", err
+	}
+	return query, nil
+}
+
+func (s *SQLiteStore) GetQueryID(sqlName string) (int, error) {
+	var id int
+	err := s.db.QueryRow("SELECT id FROM queries WHERE name = ?", sqlName).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *SQLiteStore) GetQueryView(sqlName string) (string, error) {
+	var view string
+	err := s.db.QueryRow("SELECT COALESCE(view, 'r') FROM queries WHERE name = ?", sqlName).Scan(&view)
+	if err != nil {
+		return "", err
+	}
+	return view, nil
+}
+
+func (s *SQLiteStore) GetItemID(itemName string) (int, error) {
+	var id int
+	err := s.db.QueryRow("SELECT id FROM items WHERE name = ?", itemName).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *SQLiteStore) GetDBIDFromItem(itemID int) (int, error) {
+	var idDB int
+	err := s.db.QueryRow("SELECT id_db FROM items WHERE id = ?", itemID).Scan(&idDB)
+	if err != nil {
+		return 0, err
+	}
+	return idDB, nil
+}
+
+func (s *SQLiteStore) GetConnectionStringByID(idDB int) (string, error) {
+	var connect string
+	err := s.db.QueryRow("SELECT connect FROM dbs WHERE id = ?", idDB).Scan(&connect)
+	if err != nil {
+		return "", err
+	}
+	return connect, nil
+}
+
+func (s *SQLiteStore) GetConnectionStringByItem(itemName string) (string, error) {
+	itemID, err := s.GetItemID(itemName)
+	if err != nil {
+		return "", err
+	}
+	idDB, err := s.GetDBIDFromItem(itemID)
+	if err != nil {
+		return "", err
+	}
+	return s.GetConnectionStringByID(idDB)
+}
+
+func (s *SQLiteStore) GetQueryConfig(sqlName string) (map[string]int, map[string]string, int, error) {
+	config, err := s.LoadQueryConfig(sqlName)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return config.Widths, config.Aliases, config.Height, nil
+}
+
+// LoadQueryConfig returns the full config of a saved query, with Height
+// falling back to the queries.height column.
+func (s *SQLiteStore) LoadQueryConfig(sqlName string) (QueryConfig, error) {
+	var configJSON sql.NullString
+	var tableHeight int
+	err := s.db.QueryRow("SELECT config, COALESCE(height, 10) FROM queries WHERE name = ?", sqlName).Scan(&configJSON, &tableHeight)
+	if err != nil {
+		return QueryConfig{}, err
+	}
+
+	if !configJSON.Valid || configJSON.String == "" {
+		return QueryConfig{Widths: make(map[string]int), Aliases: make(map[string]string), Height: tableHeight}, nil
+	}
+
+	var config QueryConfig
+	err = json.Unmarshal([]byte(configJSON.String), &config)
+	if err != nil {
+		return QueryConfig{}, err
+	}
+
+	if config.Height == 0 {
+		config.Height = tableHeight
+	}
+
+	return config, nil
+}
+
+// SetQueryConfigValue sets one key of a query's config JSON, leaving the
+// other keys untouched.
+func (s *SQLiteStore) SetQueryConfigValue(sqlName, key string, value interface{}) error {
+	var configJSON sql.NullString
+	err := s.db.QueryRow("SELECT config FROM queries WHERE name = ?", sqlName).Scan(&configJSON)
+	if err != nil {
+		return err
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if configJSON.Valid && configJSON.String != "" {
+		if err := json.Unmarshal([]byte(configJSON.String), &fields); err != nil {
+			return err
+		}
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	fields[key] = raw
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec("UPDATE queries SET config = ? WHERE name = ?", string(data), sqlName)
+	return err
+}
+
+// CloneQuery copies query srcName, with its config, height and view, to a
+// new query dstName. With dbName set, the copy belongs to that database's
+// first item instead of the source's item.
+func (s *SQLiteStore) CloneQuery(srcName, dstName, dbName string) error {
+	var idItem int
+	if err := s.db.QueryRow("SELECT id_item FROM queries WHERE name = ?", srcName).Scan(&idItem); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("query %s not found", srcName)
+		}
+		return err
+	}
+	if dbName != "" {
+		err := s.db.QueryRow(
+			"SELECT i.id FROM items i JOIN dbs d ON d.id = i.id_db WHERE d.name = ? ORDER BY i.id LIMIT 1",
+			dbName,
+		).Scan(&idItem)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("db %s not found or has no items", dbName)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO queries (id_item, name, query, config, height, view)
+		SELECT ?, ?, query, config, height, view FROM queries WHERE name = ?`,
+		idItem, dstName, srcName,
+	)
+	if err != nil && strings.Contains(err.Error(), "UNIQUE") {
+		return fmt.Errorf("query %s already exists", dstName)
+	}
+	return err
+}
+
+func (s *SQLiteStore) InsertItemIfNotExists(item string, idDB int) error {
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM items WHERE name = ?", item).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		_, err = s.db.Exec("INSERT INTO items (name, id_db) VALUES (?, ?)", item, idDB)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStore) InsertConfig(idItem int, uid string, row []string, cols []string, aliases map[string]string) error {
+	for i := range cols {
+		if i < len(row) {
+			colTitle := strings.ToUpper(cols[i])
+			if _, ok := aliases[colTitle]; ok {
+				varValue := row[i]
+				_, err := s.db.Exec(
+					"INSERT OR REPLACE INTO config (id_item, uid, var, val) VALUES (?, ?, ?, ?)",
+					idItem, uid, aliases[colTitle], varValue,
+				)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStore) SaveToConfig(itemName string, idDB int, uid string, row []string, cols []string, aliases map[string]string) error {
+	if err := s.InsertItemIfNotExists(itemName, idDB); err != nil {
+		return err
+	}
+	idItem, err := s.GetItemID(itemName)
+	if err != nil {
+		return err
+	}
+
+	return s.InsertConfig(idItem, uid, row, cols, aliases)
+}
+
+func (s *SQLiteStore) SaveConfigFromTable(itemName string, idDB int, uid string, row []string, cols []table.Column, aliases map[string]string) error {
+	if err := s.InsertItemIfNotExists(itemName, idDB); err != nil {
+		return err
+	}
+	idItem, err := s.GetItemID(itemName)
+	if err != nil {
+		return err
+	}
+
+	for i := range cols {
+		if i < len(row) {
+			colTitle := strings.ToUpper(cols[i].Title)
+			if _, ok := aliases[colTitle]; ok {
+				varValue := row[i]
+				_, err := s.db.Exec(
+					"INSERT OR REPLACE INTO config (id_item, uid, var, val) VALUES (?, ?, ?, ?)",
+					idItem, uid, aliases[colTitle], varValue,
+				)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStore) SaveInstance(idQuery int, hash string, providedUID string, filter string) (string, error) {
+	uid := providedUID
+	if uid == "" {
+		var err error
+		uid, err = s.generateUUID()
+		if err != nil {
+			return "", err
+		}
+	}
+	_, err := s.db.Exec(
+		"INSERT OR REPLACE INTO instance (uid, id_query, hash, filter) VALUES (?, ?, ?, ?)",
+		uid, idQuery, hash, filter,
+	)
+	if err != nil {
+		return "", err
+	}
+	return uid, nil
+}
+
+func (s *SQLiteStore) GetHashByUID(uid string, idQuery int) (string, error) {
+	var hash string
+	err := s.db.QueryRow("SELECT hash FROM instance WHERE uid = ? AND id_query = ?", uid, idQuery).Scan(&hash)
+	if err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+func (s *SQLiteStore) GetFilterByUID(uid string, idQuery int) (string, error) {
+	var filter string
+	err := s.db.QueryRow("SELECT COALESCE(filter, '') FROM instance WHERE uid = ? AND id_query = ?", uid, idQuery).Scan(&filter)
+	if err != nil {
+		return "", err
+	}
+	return filter, nil
+}
+
+func (s *SQLiteStore) GetQueryIDByHash(hash string) (int, error) {
+	var idQuery int
+	err := s.db.QueryRow("SELECT id_query FROM instance WHERE hash = ?", hash).Scan(&idQuery)
+	if err != nil {
+		return 0, err
+	}
+	return idQuery, nil
+}
+
+// SaveSnapshot materializes a result set into a new table named after the
+// query and the current time, and records it in snapshots. Column types are
+// derived from the values. It returns the new table's name.
+func (s *SQLiteStore) SaveSnapshot(sqlName string, idQuery int, uid string, cols []string, rows [][]string) (string, error) {
+	now := time.Now()
+	base := "snapshot_" + identifier(sqlName) + "_" + now.Format("20060102_150405")
+
+	defs := make([]string, len(cols))
+	for i, col := range cols {
+		defs[i] = sqlident.Quote(col) + " " + columnType(rows, i)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	// Names have one-second resolution; later snapshots within the same
+	// second get a counter suffix.
+	name := base
+	for n := 2; ; n++ {
+		var taken int
+		if err := tx.QueryRow("SELECT count(*) FROM sqlite_master WHERE name = ?", name).Scan(&taken); err != nil {
+			return "", err
+		}
+		if taken == 0 {
+			break
+		}
+		name = fmt.Sprintf("%s_%d", base, n)
+	}
+	if _, err := tx.Exec(fmt.Sprintf("CREATE TABLE %s (%s)", sqlident.Quote(name), strings.Join(defs, ", "))); err != nil {
+		return "", err
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", ")
+	stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s VALUES (%s)", sqlident.Quote(name), placeholders))
+	if err != nil {
+		return "", err
+	}
+	defer stmt.Close()
+	for _, row := range rows {
+		values := make([]interface{}, len(cols))
+		for i := range values {
+			if i < len(row) && row[i] != "" {
+				values[i] = row[i]
+			}
+		}
+		if _, err := stmt.Exec(values...); err != nil {
+			return "", err
+		}
+	}
+	if _, err := tx.Exec(
+		"INSERT INTO snapshots (name, id_query, uid, created) VALUES (?, ?, ?, ?)",
+		name, idQuery, uid, now.Format(time.RFC3339),
+	); err != nil {
+		return "", err
+	}
+	return name, tx.Commit()
+}
+
+// LatestSnapshot returns the newest snapshot table saved for a query instance.
+// Snapshots created within the same second are ordered by insertion.
+func (s *SQLiteStore) LatestSnapshot(idQuery int, uid string) (string, error) {
+	var name string
+	err := s.db.QueryRow(
+		"SELECT name FROM snapshots WHERE id_query = ? AND uid = ? ORDER BY created DESC, rowid DESC LIMIT 1",
+		idQuery, uid,
+	).Scan(&name)
+	if err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// LoadSnapshot reads back a snapshot table, rendering NULLs as empty strings.
+func (s *SQLiteStore) LoadSnapshot(name string) ([]string, [][]string, error) {
+	rows, err := s.db.Query("SELECT * FROM " + sqlident.Quote(name))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+	var result [][]string
+	for rows.Next() {
+		values := make([]sql.NullString, len(cols))
+		pointers := make([]interface{}, len(cols))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, nil, err
+		}
+		row := make([]string, len(cols))
+		for i, v := range values {
+			row[i] = v.String
+		}
+		result = append(result, row)
+	}
+	return cols, result, rows.Err()
+}
+
+func (s *SQLiteStore) generateUUID() (string, error) {
+	var hex string
+	err := s.db.QueryRow("SELECT lower(hex(randomblob(16)))").Scan(&hex)
+	if err != nil {
+		return "", err
+	}
+	uid := fmt.Sprintf("%s-%s-%s-%s-%s",
+		hex[0:8], hex[8:12], hex[12:16], hex[16:20], hex[20:32])
+	return uid, nil
+}
+
+// columnType picks INTEGER or REAL when every non-empty value in the
+// column parses as one, and TEXT otherwise.
+func columnType(rows [][]string, col int) string {
+	colType := "INTEGER"
+	for _, row := range rows {
+		if col >= len(row) || row[col] == "" {
+			continue
+		}
+		if _, err := strconv.ParseInt(row[col], 10, 64); err == nil {
+			continue
+		}
+		if _, err := strconv.ParseFloat(row[col], 64); err == nil {
+			colType = "REAL"
+			continue
+		}
+		return "TEXT"
+	}
+	return colType
+}
+
+// identifier reduces s to letters, digits and underscores.
+func identifier(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// SaveLastRun records run as the invocation tel last repeats.
+func (s *SQLiteStore) SaveLastRun(run LastRun) error {
+	_, err := s.db.Exec(
+		"INSERT OR REPLACE INTO state (id, db, item, sql, query, filter, uid) VALUES (1, ?, ?, ?, ?, ?, ?)",
+		run.DB, run.Item, run.SQL, run.Query, run.Filter, run.UID,
+	)
+	return err
+}
+
+// GetLastRun returns the invocation saved by SaveLastRun; sql.ErrNoRows
+// when there is none.
+func (s *SQLiteStore) GetLastRun() (LastRun, error) {
+	var run LastRun
+	err := s.db.QueryRow(`
+		SELECT COALESCE(db, ''), COALESCE(item, ''), COALESCE(sql, ''), COALESCE(query, ''),
+			COALESCE(filter, ''), COALESCE(uid, '')
+		FROM state WHERE id = 1`,
+	).Scan(&run.DB, &run.Item, &run.SQL, &run.Query, &run.Filter, &run.UID)
+	return run, err
+}
@@ -0,0 +1,71 @@
+package config
+
+import "database/sql"
+
+// ConfigSnapshot is the config of one item instance as it was before a
+// save, for undoing it.
+type ConfigSnapshot struct {
+	ItemName string
+	UID      string
+	// Values maps var to val; vars missing from it didn't exist.
+	Values map[string]string
+}
+
+// TakeConfigSnapshot records the config vars saved for itemName and uid.
+// An item that doesn't exist yet has an empty snapshot.
+func (s *SQLiteStore) TakeConfigSnapshot(itemName, uid string) (ConfigSnapshot, error) {
+	snap := ConfigSnapshot{ItemName: itemName, UID: uid, Values: map[string]string{}}
+	rows, err := s.db.Query(`
+		SELECT c.var, COALESCE(c.val, '')
+		FROM config c JOIN items i ON i.id = c.id_item
+		WHERE i.name = ? AND c.uid = ?`,
+		itemName, uid,
+	)
+	if err != nil {
+		return ConfigSnapshot{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name, val string
+		if err := rows.Scan(&name, &val); err != nil {
+			return ConfigSnapshot{}, err
+		}
+		snap.Values[name] = val
+	}
+	if err := rows.Err(); err != nil {
+		return ConfigSnapshot{}, err
+	}
+	return snap, nil
+}
+
+// RestoreConfigSnapshot replaces the config vars of the snapshot's item
+// instance with the ones it recorded.
+func (s *SQLiteStore) RestoreConfigSnapshot(snap ConfigSnapshot) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var idItem int
+	err = tx.QueryRow("SELECT id FROM items WHERE name = ?", snap.ItemName).Scan(&idItem)
+	if err == sql.ErrNoRows {
+		// Nothing was ever saved for it.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM config WHERE id_item = ? AND uid = ?", idItem, snap.UID); err != nil {
+		return err
+	}
+	for name, val := range snap.Values {
+		if _, err := tx.Exec(
+			"INSERT INTO config (id_item, uid, var, val) VALUES (?, ?, ?, ?)",
+			idItem, snap.UID, name, val,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
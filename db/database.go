@@ -1,11 +1,24 @@
 package db
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/bubbles/table"
 	_ "github.com/jackc/pgx/v5/stdlib"
@@ -15,33 +28,148 @@ import (
 
 type DB struct {
 	*sql.DB
+	// Driver is the registered name of the connected driver.
+	Driver           string
 	Path             string
 	ConnectionString string
+	Options          Options
+}
+
+// Options controls how GetContent renders values into table cells.
+type Options struct {
+	// BinaryEncoding is "base64" (default), "hex" or "raw".
+	BinaryEncoding string
+	// TimeFormat is a Go time layout, time.RFC3339 by default.
+	TimeFormat string
+	// BoolFormat is the text for true and false separated by "/", e.g.
+	// "yes/no" or "✓/✗"; "true/false" by default.
+	BoolFormat string
+	// NullText and EmptyText show NULLs and empty strings; both are empty
+	// cells by default.
+	NullText  string
+	EmptyText string
+}
+
+func SetOptions(opts Options) {
+	db.Options = opts
 }
 
 var db DB
 
-func Connect(driver string, connectionString string) error {
-	sqlDB, err := sql.Open(driver, connectionString)
+// QueryExecutor runs the queries behind GetContent.
+type QueryExecutor interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+type sqlQueryExecutor struct {
+	db *sql.DB
+}
+
+// NewSQLQueryExecutor returns a QueryExecutor backed by d.
+func NewSQLQueryExecutor(d *sql.DB) QueryExecutor {
+	return sqlQueryExecutor{db: d}
+}
+
+func (e sqlQueryExecutor) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return e.db.Query(query, args...)
+}
+
+func (e sqlQueryExecutor) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return e.db.QueryContext(ctx, query, args...)
+}
+
+// contextQueryExecutor is a QueryExecutor whose queries can be cancelled.
+type contextQueryExecutor interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// executor is set by Connect; tests can replace it with SetExecutor.
+var executor QueryExecutor
+
+// SetExecutor replaces the executor GetContent runs queries with.
+func SetExecutor(e QueryExecutor) {
+	executor = e
+}
+
+// driverAliases maps friendly driver names to the names registered by the
+// drivers compiled into tel.
+var driverAliases = map[string]string{
+	"postgres":   "pgx",
+	"postgresql": "pgx",
+	"pg":         "pgx",
+	"sqlite3":    "sqlite",
+	"duck":       "duckdb",
+}
+
+// NormalizeDriver resolves a friendly driver name to its registered name.
+// Unknown names are returned unchanged.
+func NormalizeDriver(driver string) string {
+	if name, ok := driverAliases[strings.ToLower(driver)]; ok {
+		return name
+	}
+	return driver
+}
+
+// Connect opens and pings the database, then runs ~/.duckdbrc for local
+// DuckDB databases and initSQL, if any, for every driver. Settings made by
+// initSQL only hold on the connection that ran it, so when it has any
+// statements the pool is kept to that one connection.
+func Connect(driver string, connectionString string, initSQL string) error {
+	driver = NormalizeDriver(driver)
+	if !slices.Contains(Drivers(), driver) {
+		return fmt.Errorf("%w: %s; available: %s", ErrDriverNotCompiled, driver, strings.Join(Drivers(), ", "))
+	}
+
+	motherDuck := driver == "duckdb" && strings.HasPrefix(connectionString, "md:")
+	dsn := connectionString
+	if motherDuck {
+		dsn = withMotherDuckToken(dsn)
+	}
+	sqlDB, err := sql.Open(driver, dsn)
 	if err != nil {
 		return err
 	}
 
 	if err = sqlDB.Ping(); err != nil {
+		sqlDB.Close()
 		return err
 	}
 
-	if driver == "duckdb" {
+	// ~/.duckdbrc is meant for local databases, not MotherDuck ones.
+	if driver == "duckdb" && !motherDuck {
 		if err := executeDuckDBRC(sqlDB); err != nil {
+			sqlDB.Close()
 			return err
 		}
 	}
 
+	if err := executeInitSQL(sqlDB, initSQL); err != nil {
+		sqlDB.Close()
+		return err
+	}
+
 	db.DB = sqlDB
+	db.Driver = driver
 	db.ConnectionString = connectionString
+	executor = NewSQLQueryExecutor(sqlDB)
 	return nil
 }
 
+// withMotherDuckToken adds $MOTHERDUCK_TOKEN to an md: connection string
+// that has no token. The token is needed when the connection opens, so it
+// can't be SET afterwards.
+func withMotherDuckToken(connectionString string) string {
+	token := os.Getenv("MOTHERDUCK_TOKEN")
+	if token == "" || strings.Contains(connectionString, "motherduck_token=") {
+		return connectionString
+	}
+	sep := "?"
+	if strings.Contains(connectionString, "?") {
+		sep = "&"
+	}
+	return connectionString + sep + "motherduck_token=" + url.QueryEscape(token)
+}
+
 func executeDuckDBRC(sqlDB *sql.DB) error {
 	rcPath := filepath.Join(os.Getenv("HOME"), ".duckdbrc")
 	data, err := os.ReadFile(rcPath)
@@ -55,20 +183,115 @@ func executeDuckDBRC(sqlDB *sql.DB) error {
 	return err
 }
 
+// executeInitSQL runs the ;-separated statements of initSQL one by one.
+func executeInitSQL(sqlDB *sql.DB, initSQL string) error {
+	stmts := splitStatements(initSQL)
+	if len(stmts) == 0 {
+		return nil
+	}
+	// Settings like search_path are per connection, so keep just the one.
+	sqlDB.SetMaxOpenConns(1)
+	for _, stmt := range stmts {
+		if _, err := sqlDB.Exec(stmt); err != nil {
+			return fmt.Errorf("init SQL %q failed: %w", stmt, err)
+		}
+	}
+	return nil
+}
+
+// splitStatements splits sql on semicolons outside quotes, comments and
+// dollar-quoted bodies such as $$...$$, and drops statements that are
+// empty or only comments.
+func splitStatements(sql string) []string {
+	var stmts []string
+	start, code := 0, false
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case strings.HasPrefix(sql[i:], "--"):
+			i = skipPast(sql, i+2, "\n")
+			continue
+		case strings.HasPrefix(sql[i:], "/*"):
+			i = skipPast(sql, i+2, "*/")
+			continue
+		case c == ';':
+			if code {
+				stmts = append(stmts, strings.TrimSpace(sql[start:i]))
+			}
+			start, code = i+1, false
+			continue
+		case c == '\'' || c == '"':
+			i = skipPast(sql, i+1, string(c))
+		case c == '$':
+			if tag := dollarTag.FindString(sql[i:]); tag != "" {
+				i = skipPast(sql, i+len(tag), tag)
+			}
+		}
+		if !unicode.IsSpace(rune(c)) {
+			code = true
+		}
+	}
+	if code {
+		stmts = append(stmts, strings.TrimSpace(sql[start:]))
+	}
+	return stmts
+}
+
+// dollarTag matches the $$ or $tag$ opening a dollar-quoted body; $1
+// parameters don't.
+var dollarTag = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
+
+// skipPast returns the index of the last byte of the first end in sql at
+// or after from, or len(sql) when there is none.
+func skipPast(sql string, from int, end string) int {
+	if j := strings.Index(sql[from:], end); j >= 0 {
+		return from + j + len(end) - 1
+	}
+	return len(sql)
+}
+
 func Close() error {
 	return db.Close()
 }
 
 func GetContent(sqlQuery string) ([]table.Row, []table.Column, error) {
-	rows, err := db.Query(sqlQuery)
+	return GetContentContext(context.Background(), sqlQuery)
+}
+
+// GetContentContext is GetContent with a context that cancels the query.
+// Executors without QueryContext run the query uncancelled.
+func GetContentContext(ctx context.Context, sqlQuery string) ([]table.Row, []table.Column, error) {
+	rows, cols, _, err := GetTypedContentContext(ctx, sqlQuery)
+	return rows, cols, err
+}
+
+// GetTypedContentContext is GetContentContext also returning the database
+// type name of each column, upper-cased, or "" where the driver has none.
+func GetTypedContentContext(ctx context.Context, sqlQuery string) ([]table.Row, []table.Column, []string, error) {
+	if executor == nil {
+		return nil, nil, nil, errors.New("not connected")
+	}
+	var rows *sql.Rows
+	var err error
+	if e, ok := executor.(contextQueryExecutor); ok {
+		rows, err = e.QueryContext(ctx, sqlQuery)
+	} else {
+		rows, err = executor.Query(sqlQuery)
+	}
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	defer rows.Close()
 
 	cols, err := rows.Columns()
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
+	}
+	dbTypes := make([]string, len(cols))
+	if colTypes, err := rows.ColumnTypes(); err == nil {
+		for i, ct := range colTypes {
+			dbTypes[i] = strings.ToUpper(ct.DatabaseTypeName())
+		}
 	}
 
 	var result []table.Row
@@ -79,27 +302,198 @@ func GetContent(sqlQuery string) ([]table.Row, []table.Column, error) {
 			pointers[i] = &values[i]
 		}
 		if err := rows.Scan(pointers...); err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 		row := make(table.Row, len(cols))
 		for i, v := range values {
-			switch val := v.(type) {
-			case nil:
-				row[i] = ""
-			case []byte:
-				row[i] = string(val)
-			case string:
-				row[i] = val
+			switch cell := formatColumnValue(v, dbTypes[i]); {
+			case v == nil:
+				row[i] = db.Options.NullText
+			case cell == "":
+				row[i] = db.Options.EmptyText
 			default:
-				row[i] = fmt.Sprintf("%v", val)
+				row[i] = cell
 			}
 		}
 		result = append(result, row)
 	}
+	// A cancelled or failed query ends the rows early.
+	if err := rows.Err(); err != nil {
+		return nil, nil, nil, err
+	}
 
 	tableCols := make([]table.Column, len(cols))
 	for i, col := range cols {
 		tableCols[i] = table.Column{Title: strings.ToUpper(col), Width: 20}
 	}
-	return result, tableCols, nil
+	return result, tableCols, dbTypes, nil
+}
+
+// formatColumnValue formats v, a value of a column whose database type is
+// dbType. JSON columns are compacted; pgx returns them as the server's
+// text, which for jsonb adds spaces after every separator.
+func formatColumnValue(v interface{}, dbType string) string {
+	// Drivers return booleans as bool, 0/1 or "t"/"f".
+	if dbType == "BOOL" || dbType == "BOOLEAN" {
+		if b, ok := parseBool(v); ok {
+			return formatBool(b)
+		}
+	}
+	if dbType == "JSON" || dbType == "JSONB" {
+		var raw []byte
+		switch val := v.(type) {
+		case []byte:
+			raw = val
+		case string:
+			raw = []byte(val)
+		}
+		var compact bytes.Buffer
+		if raw != nil && json.Compact(&compact, raw) == nil {
+			return compact.String()
+		}
+	}
+	return formatValue(v)
+}
+
+func formatValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return formatBytes(val, db.Options.BinaryEncoding)
+	case string:
+		return val
+	case bool:
+		return formatBool(val)
+	case time.Time:
+		if db.Options.TimeFormat != "" {
+			return val.Format(db.Options.TimeFormat)
+		}
+		return val.Format(time.RFC3339)
+	case []interface{}:
+		return formatArray(val)
+	case fmt.Stringer:
+		// Covers decimal types like *big.Rat, apd.Decimal or shopspring's Decimal.
+		return val.String()
+	default:
+		// Typed slices such as []int64 or []string from array columns.
+		rv := reflect.ValueOf(val)
+		if rv.Kind() == reflect.Slice {
+			elems := make([]interface{}, rv.Len())
+			for i := range elems {
+				elems[i] = rv.Index(i).Interface()
+			}
+			return formatArray(elems)
+		}
+		// Structs and maps, e.g. DuckDB STRUCT and MAP columns, as JSON.
+		if rv.Kind() == reflect.Map {
+			if b, err := json.Marshal(jsonValue(val)); err == nil {
+				return string(b)
+			}
+		}
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+func parseBool(v interface{}) (bool, bool) {
+	switch val := v.(type) {
+	case bool:
+		return val, true
+	case int64:
+		return val != 0, val == 0 || val == 1
+	case []byte:
+		return parseBool(string(val))
+	case string:
+		switch strings.ToLower(val) {
+		case "t", "true", "1":
+			return true, true
+		case "f", "false", "0":
+			return false, true
+		}
+	}
+	return false, false
+}
+
+func formatBool(b bool) string {
+	text := [2]string{"true", "false"}
+	if t, f, ok := strings.Cut(db.Options.BoolFormat, "/"); ok {
+		text = [2]string{t, f}
+	}
+	if b {
+		return text[0]
+	}
+	return text[1]
+}
+
+// formatArray renders elems as a Postgres array literal, e.g. {1,2,3} or
+// {"a b",NULL}, quoting elements that would otherwise be ambiguous.
+func formatArray(elems []interface{}) string {
+	parts := make([]string, len(elems))
+	for i, elem := range elems {
+		if elem == nil {
+			parts[i] = "NULL"
+			continue
+		}
+		part := formatValue(elem)
+		nested := strings.HasPrefix(part, "{") && reflect.ValueOf(elem).Kind() == reflect.Slice
+		if !nested && (part == "" || strings.EqualFold(part, "NULL") || strings.ContainsAny(part, ",{}\"\\ \t\n")) {
+			part = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(part) + `"`
+		}
+		parts[i] = part
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// jsonValue converts maps with non-string keys, which encoding/json
+// rejects, to maps keyed by the keys' formatted values.
+func jsonValue(v interface{}) interface{} {
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Map:
+		m := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			m[formatValue(iter.Key().Interface())] = jsonValue(iter.Value().Interface())
+		}
+		return m
+	case reflect.Slice:
+		if _, ok := v.([]byte); ok {
+			return formatValue(v)
+		}
+		elems := make([]interface{}, rv.Len())
+		for i := range elems {
+			elems[i] = jsonValue(rv.Index(i).Interface())
+		}
+		return elems
+	}
+	return v
+}
+
+// formatBytes renders binary values that aren't valid UTF-8 text using the
+// configured encoding so bytea/BLOB columns stay readable.
+func formatBytes(val []byte, encoding string) string {
+	if encoding == "raw" || utf8.Valid(val) {
+		return string(val)
+	}
+	if encoding == "hex" {
+		return "hex:" + hex.EncodeToString(val)
+	}
+	return "base64:" + base64.StdEncoding.EncodeToString(val)
+}
+
+// NamedColumns returns a copy of cols titled with their aliases from
+// columnAliases, whose keys match column titles in any case. Columns
+// without an alias keep their titles.
+func NamedColumns(cols []table.Column, columnAliases map[string]string) []table.Column {
+	upper := make(map[string]string, len(columnAliases))
+	for name, alias := range columnAliases {
+		upper[strings.ToUpper(name)] = alias
+	}
+	named := make([]table.Column, len(cols))
+	copy(named, cols)
+	for i, col := range cols {
+		if alias := upper[strings.ToUpper(col.Title)]; alias != "" {
+			named[i].Title = alias
+		}
+	}
+	return named
 }
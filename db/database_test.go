@@ -0,0 +1,319 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/table"
+)
+
+// useSQLite points GetContent at an in-memory SQLite database set up by
+// setup, with opts as the formatting options.
+func useSQLite(t *testing.T, setup string, opts Options) {
+	t.Helper()
+	sqlDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	// Every connection would get its own in-memory database.
+	sqlDB.SetMaxOpenConns(1)
+	if _, err := sqlDB.Exec(setup); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	SetExecutor(NewSQLQueryExecutor(sqlDB))
+	SetOptions(opts)
+	t.Cleanup(func() {
+		SetExecutor(nil)
+		SetOptions(Options{})
+		sqlDB.Close()
+	})
+}
+
+func titles(cols []table.Column) []string {
+	names := make([]string, len(cols))
+	for i, col := range cols {
+		names[i] = col.Title
+	}
+	return names
+}
+
+const typesSetup = `
+CREATE TABLE types (i INTEGER, r REAL, s TEXT, b BLOB, text_blob BLOB, flag BOOLEAN, at DATETIME, n TEXT);
+INSERT INTO types VALUES (42, 2.5, 'abc', x'00ff10', CAST('txt' AS BLOB), 1, '2024-03-01 12:30:00', NULL);
+`
+
+func TestGetContentTypes(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want []string
+	}{
+		{"defaults", Options{},
+			[]string{"42", "2.5", "abc", "base64:AP8Q", "txt", "true", "2024-03-01T12:30:00Z", ""}},
+		{"hex binary encoding", Options{BinaryEncoding: "hex"},
+			[]string{"42", "2.5", "abc", "hex:00ff10", "txt", "true", "2024-03-01T12:30:00Z", ""}},
+		{"time and bool formats", Options{TimeFormat: "2006-01-02", BoolFormat: "yes/no"},
+			[]string{"42", "2.5", "abc", "base64:AP8Q", "txt", "yes", "2024-03-01", ""}},
+		{"null and empty text", Options{NullText: "∅", EmptyText: "—"},
+			[]string{"42", "2.5", "abc", "base64:AP8Q", "txt", "true", "2024-03-01T12:30:00Z", "∅"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useSQLite(t, typesSetup, tt.opts)
+			rows, cols, err := GetContent("SELECT * FROM types")
+			if err != nil {
+				t.Fatalf("GetContent: %v", err)
+			}
+			wantTitles := []string{"I", "R", "S", "B", "TEXT_BLOB", "FLAG", "AT", "N"}
+			if got := titles(cols); !slices.Equal(got, wantTitles) {
+				t.Errorf("titles = %v, want %v", got, wantTitles)
+			}
+			if len(rows) != 1 {
+				t.Fatalf("got %d rows, want 1", len(rows))
+			}
+			if !slices.Equal(rows[0], tt.want) {
+				t.Errorf("row = %q, want %q", rows[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestGetContentRawBinary(t *testing.T) {
+	useSQLite(t, "CREATE TABLE t (b BLOB); INSERT INTO t VALUES (x'ff');", Options{BinaryEncoding: "raw"})
+	rows, _, err := GetContent("SELECT b FROM t")
+	if err != nil {
+		t.Fatalf("GetContent: %v", err)
+	}
+	if rows[0][0] != "\xff" {
+		t.Errorf("raw blob = %q, want %q", rows[0][0], "\xff")
+	}
+}
+
+func TestGetContentEmptyString(t *testing.T) {
+	useSQLite(t, "CREATE TABLE t (s TEXT, n TEXT); INSERT INTO t VALUES ('', NULL);", Options{EmptyText: "—"})
+	rows, _, err := GetContent("SELECT s, n FROM t")
+	if err != nil {
+		t.Fatalf("GetContent: %v", err)
+	}
+	if want := []string{"—", ""}; !slices.Equal(rows[0], want) {
+		t.Errorf("row = %q, want %q", rows[0], want)
+	}
+}
+
+func TestNormalizeDriver(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"postgres", "pgx"},
+		{"postgresql", "pgx"},
+		{"pg", "pgx"},
+		{"Postgres", "pgx"},
+		{"sqlite3", "sqlite"},
+		{"duck", "duckdb"},
+		// Registered names and unknown ones pass through.
+		{"pgx", "pgx"},
+		{"sqlite", "sqlite"},
+		{"duckdb", "duckdb"},
+		{"oracle", "oracle"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeDriver(tt.in); got != tt.want {
+			t.Errorf("NormalizeDriver(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatValueArrays(t *testing.T) {
+	tests := []struct {
+		name string
+		in   interface{}
+		want string
+	}{
+		{"interface slice", []interface{}{int64(1), int64(2), int64(3)}, "{1,2,3}"},
+		{"typed slice", []int64{4, 5}, "{4,5}"},
+		{"strings needing quotes", []string{"a b", "c", ""}, `{"a b",c,""}`},
+		{"null element", []interface{}{"x", nil}, "{x,NULL}"},
+		{"nested", []interface{}{[]int64{1, 2}, []int64{3}}, "{{1,2},{3}}"},
+		{"empty", []interface{}{}, "{}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatValue(tt.in); got != tt.want {
+				t.Errorf("formatValue(%v) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+// mockResult is what the mock driver returns for any query: values as a
+// driver such as pgx would, which SQLite can't produce.
+type mockResult struct {
+	cols  []string
+	types []string
+	rows  [][]driver.Value
+	// err ends the rows instead of io.EOF when set.
+	err error
+}
+
+type mockConnector struct{ result mockResult }
+
+func (c mockConnector) Connect(context.Context) (driver.Conn, error) { return mockConn(c), nil }
+func (c mockConnector) Driver() driver.Driver                        { return nil }
+
+type mockConn struct{ result mockResult }
+
+func (c mockConn) Prepare(string) (driver.Stmt, error) { return mockStmt(c), nil }
+func (mockConn) Close() error                          { return nil }
+func (mockConn) Begin() (driver.Tx, error)             { return nil, errors.New("mock: no transactions") }
+
+type mockStmt struct{ result mockResult }
+
+func (mockStmt) Close() error  { return nil }
+func (mockStmt) NumInput() int { return -1 }
+func (mockStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("mock: no exec")
+}
+func (s mockStmt) Query([]driver.Value) (driver.Rows, error) {
+	return &mockRows{result: s.result}, nil
+}
+
+type mockRows struct {
+	result mockResult
+	next   int
+}
+
+func (r *mockRows) Columns() []string { return r.result.cols }
+func (r *mockRows) Close() error      { return nil }
+func (r *mockRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.result.rows) {
+		if r.result.err != nil {
+			return r.result.err
+		}
+		return io.EOF
+	}
+	copy(dest, r.result.rows[r.next])
+	r.next++
+	return nil
+}
+func (r *mockRows) ColumnTypeDatabaseTypeName(i int) string { return r.result.types[i] }
+
+// useMockRows points GetContent at a database returning result, with opts
+// as the formatting options.
+func useMockRows(t *testing.T, result mockResult, opts Options) {
+	t.Helper()
+	sqlDB := sql.OpenDB(mockConnector{result})
+	SetExecutor(NewSQLQueryExecutor(sqlDB))
+	SetOptions(opts)
+	t.Cleanup(func() {
+		SetExecutor(nil)
+		SetOptions(Options{})
+		sqlDB.Close()
+	})
+}
+
+func TestGetContentArrays(t *testing.T) {
+	useMockRows(t, mockResult{
+		cols:  []string{"ids", "tags", "matrix"},
+		types: []string{"_INT4", "_TEXT", "_INT4"},
+		rows: [][]driver.Value{
+			{[]interface{}{int64(1), int64(2), int64(3)}, []string{"a", "b c"}, []interface{}{[]int64{1, 2}, []int64{3, 4}}},
+			{[]interface{}{int64(4), nil}, []string{}, nil},
+		},
+	}, Options{})
+
+	rows, _, err := GetContent("SELECT ids, tags, matrix FROM t")
+	if err != nil {
+		t.Fatalf("GetContent: %v", err)
+	}
+	want := []table.Row{
+		{"{1,2,3}", `{a,"b c"}`, "{{1,2},{3,4}}"},
+		{"{4,NULL}", "{}", ""},
+	}
+	if !slices.EqualFunc(rows, want, slices.Equal) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+}
+
+func TestGetContentBools(t *testing.T) {
+	// Drivers return booleans as bool, 0/1 or "t"/"f".
+	result := mockResult{
+		cols:  []string{"native", "int", "text"},
+		types: []string{"BOOL", "BOOLEAN", "BOOL"},
+		rows: [][]driver.Value{
+			{true, int64(1), []byte("t")},
+			{false, int64(0), []byte("f")},
+		},
+	}
+	tests := []struct {
+		name string
+		opts Options
+		want []table.Row
+	}{
+		{"default", Options{}, []table.Row{{"true", "true", "true"}, {"false", "false", "false"}}},
+		{"bool_format", Options{BoolFormat: "✓/✗"}, []table.Row{{"✓", "✓", "✓"}, {"✗", "✗", "✗"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useMockRows(t, result, tt.opts)
+			rows, _, err := GetContent("SELECT native, int, text FROM t")
+			if err != nil {
+				t.Fatalf("GetContent: %v", err)
+			}
+			if !slices.EqualFunc(rows, tt.want, slices.Equal) {
+				t.Errorf("rows = %q, want %q", rows, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetContentRowsError(t *testing.T) {
+	failed := errors.New("connection reset")
+	useMockRows(t, mockResult{
+		cols:  []string{"id"},
+		types: []string{"INT8"},
+		rows:  [][]driver.Value{{int64(1)}, {int64(2)}},
+		err:   failed,
+	}, Options{})
+
+	rows, _, err := GetContent("SELECT id FROM t")
+	if !errors.Is(err, failed) {
+		t.Fatalf("GetContent error = %v, want %v", err, failed)
+	}
+	if rows != nil {
+		t.Errorf("GetContent returned %d rows of a failed query", len(rows))
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name, sql string
+		want      []string
+	}{
+		{"plain", "SET a = 1; SET b = 2;", []string{"SET a = 1", "SET b = 2"}},
+		{"empty", " ; ;", nil},
+		{"quotes", `SET a = ';'; SELECT "x;y"`, []string{`SET a = ';'`, `SELECT "x;y"`}},
+		{"doubled quote", "SELECT 'it''s; fine'; SELECT 2", []string{"SELECT 'it''s; fine'", "SELECT 2"}},
+		{"line comment", "SET a = 1; -- not; a statement\nSET b = 2", []string{"SET a = 1", "-- not; a statement\nSET b = 2"}},
+		{"block comment", "SET a = 1 /* ; */; SET b = 2", []string{"SET a = 1 /* ; */", "SET b = 2"}},
+		{"trailing comment only", "SET a = 1; -- done", []string{"SET a = 1"}},
+		{"dollar body", "CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql; SELECT f()",
+			[]string{"CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql", "SELECT f()"}},
+		{"tagged dollar body", "DO $body$ BEGIN PERFORM 1; END $body$; SELECT 1",
+			[]string{"DO $body$ BEGIN PERFORM 1; END $body$", "SELECT 1"}},
+		{"parameter", "SELECT $1; SELECT 2", []string{"SELECT $1", "SELECT 2"}},
+		{"unterminated quote", "SELECT 'a; b", []string{"SELECT 'a; b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.sql); !slices.Equal(got, tt.want) {
+				t.Errorf("splitStatements(%q) = %q, want %q", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
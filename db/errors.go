@@ -0,0 +1,47 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrDriverNotCompiled is returned by Connect for a driver tel wasn't
+// built with.
+var ErrDriverNotCompiled = errors.New("driver not compiled in")
+
+// ExplainConnectError turns an error returned by Connect into a message
+// a user can act on without reading the log file.
+func ExplainConnectError(driver string, err error) string {
+	var pgErr *pgconn.PgError
+	var netErr net.Error
+	var dnsErr *net.DNSError
+
+	switch {
+	case errors.Is(err, ErrDriverNotCompiled):
+		return fmt.Sprintf("unknown driver %q; tel was built with: %s", driver, strings.Join(Drivers(), ", "))
+	case errors.As(err, &pgErr) && (pgErr.Code == "28P01" || pgErr.Code == "28000"),
+		strings.Contains(err.Error(), "password authentication failed"):
+		return fmt.Sprintf("authentication failed for %s connection: %v", driver, err)
+	case errors.As(err, &dnsErr),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.EHOSTUNREACH),
+		errors.Is(err, syscall.ENETUNREACH),
+		errors.Is(err, syscall.ETIMEDOUT),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &netErr):
+		return fmt.Sprintf("database host unreachable for %s connection: %v", driver, err)
+	}
+	return fmt.Sprintf("failed to connect using driver %q: %v", driver, err)
+}
+
+// Drivers returns the sorted names of the SQL drivers compiled into tel.
+func Drivers() []string {
+	return sql.Drivers()
+}
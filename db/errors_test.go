@@ -0,0 +1,17 @@
+package db
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConnectDriverNotCompiled(t *testing.T) {
+	err := Connect("oracle", "", "")
+	if !errors.Is(err, ErrDriverNotCompiled) {
+		t.Fatalf("Connect error = %v, want ErrDriverNotCompiled", err)
+	}
+	if msg := ExplainConnectError("oracle", err); !strings.HasPrefix(msg, `unknown driver "oracle"`) {
+		t.Errorf("ExplainConnectError = %q, want an unknown driver message", msg)
+	}
+}
@@ -0,0 +1,133 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+)
+
+// ExplainJSON runs the plan of query on the connected database and returns
+// it one line per row, under a single QUERY PLAN column. PostgreSQL's JSON
+// plan and SQLite's plan rows are drawn as a tree; DuckDB draws its own.
+func ExplainJSON(query, driver string) ([]table.Row, []table.Column, error) {
+	var lines []string
+	switch NormalizeDriver(driver) {
+	case "pgx":
+		rows, _, err := GetContent("EXPLAIN (FORMAT JSON) " + query)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(rows) == 0 || len(rows[0]) == 0 {
+			return nil, nil, fmt.Errorf("EXPLAIN returned no plan")
+		}
+		var plans []struct {
+			Plan pgPlan `json:"Plan"`
+		}
+		if err := json.Unmarshal([]byte(rows[0][0]), &plans); err != nil {
+			return nil, nil, fmt.Errorf("parsing plan: %w", err)
+		}
+		for _, p := range plans {
+			lines = append(lines, p.Plan.tree("", "")...)
+		}
+	case "sqlite":
+		// Rows are id, parent, notused, detail, parents before children.
+		rows, _, err := GetContent("EXPLAIN QUERY PLAN " + query)
+		if err != nil {
+			return nil, nil, err
+		}
+		children := map[string][]table.Row{}
+		for _, row := range rows {
+			children[row[1]] = append(children[row[1]], row)
+		}
+		var walk func(parent, indent string)
+		walk = func(parent, indent string) {
+			for i, row := range children[parent] {
+				branch, next := treeBranch(i == len(children[parent])-1)
+				lines = append(lines, indent+branch+row[3])
+				walk(row[0], indent+next)
+			}
+		}
+		walk("0", "")
+	case "duckdb":
+		// DuckDB has no EXPLAIN QUERY PLAN; EXPLAIN returns the drawn plan.
+		rows, _, err := GetContent("EXPLAIN " + query)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, row := range rows {
+			lines = append(lines, strings.Split(strings.TrimRight(row[len(row)-1], "\n"), "\n")...)
+		}
+	default:
+		return nil, nil, fmt.Errorf("explain isn't supported for driver %s", driver)
+	}
+
+	result := make([]table.Row, len(lines))
+	width := 0
+	for i, line := range lines {
+		result[i] = table.Row{line}
+		width = max(width, len([]rune(line)))
+	}
+	return result, []table.Column{{Title: "QUERY PLAN", Width: width}}, nil
+}
+
+// pgPlan is a node of a PostgreSQL JSON plan, keeping what the tree shows.
+type pgPlan struct {
+	NodeType     string   `json:"Node Type"`
+	RelationName string   `json:"Relation Name"`
+	Alias        string   `json:"Alias"`
+	IndexName    string   `json:"Index Name"`
+	JoinType     string   `json:"Join Type"`
+	StartupCost  float64  `json:"Startup Cost"`
+	TotalCost    float64  `json:"Total Cost"`
+	PlanRows     float64  `json:"Plan Rows"`
+	Filter       string   `json:"Filter"`
+	IndexCond    string   `json:"Index Cond"`
+	HashCond     string   `json:"Hash Cond"`
+	MergeCond    string   `json:"Merge Cond"`
+	JoinFilter   string   `json:"Join Filter"`
+	SortKey      []string `json:"Sort Key"`
+	Plans        []pgPlan `json:"Plans"`
+}
+
+// tree draws p and its children, p's line starting with indent+branch and
+// the lines under it with indent+next.
+func (p pgPlan) tree(branch, next string) []string {
+	node := p.NodeType
+	if p.JoinType != "" && p.JoinType != "Inner" {
+		node = p.JoinType + " " + node
+	}
+	if p.IndexName != "" {
+		node += " using " + p.IndexName
+	}
+	if p.RelationName != "" {
+		node += " on " + p.RelationName
+		if p.Alias != "" && p.Alias != p.RelationName {
+			node += " " + p.Alias
+		}
+	}
+	lines := []string{fmt.Sprintf("%s%s  (cost=%.2f..%.2f rows=%.0f)", branch, node, p.StartupCost, p.TotalCost, p.PlanRows)}
+	for _, detail := range [][2]string{
+		{"Index Cond", p.IndexCond}, {"Hash Cond", p.HashCond}, {"Merge Cond", p.MergeCond},
+		{"Join Filter", p.JoinFilter}, {"Filter", p.Filter}, {"Sort Key", strings.Join(p.SortKey, ", ")},
+	} {
+		if detail[1] != "" {
+			lines = append(lines, next+"    "+detail[0]+": "+detail[1])
+		}
+	}
+	for i, child := range p.Plans {
+		b, n := treeBranch(i == len(p.Plans)-1)
+		lines = append(lines, child.tree(next+b, next+n)...)
+	}
+	return lines
+}
+
+// treeBranch returns the prefix of a tree node and the one continuing
+// under it, depending on whether it is its parent's last child.
+func treeBranch(last bool) (branch, next string) {
+	if last {
+		return "└─ ", "   "
+	}
+	return "├─ ", "│  "
+}
@@ -0,0 +1,21 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ExportParquet writes the result set of query to a Parquet file at
+// outputPath with DuckDB's COPY. Other drivers can't write Parquet.
+func ExportParquet(query, outputPath string) error {
+	if db.DB == nil {
+		return errors.New("not connected")
+	}
+	if db.Driver != "duckdb" {
+		return fmt.Errorf("parquet output needs a duckdb connection, not %s", db.Driver)
+	}
+	_, err := db.Exec(fmt.Sprintf("COPY (%s) TO '%s' (FORMAT PARQUET)",
+		query, strings.ReplaceAll(outputPath, "'", "''")))
+	return err
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"mcold/tel/internal/sqlident"
+)
+
+// TableStats summarizes a table for tel stats. Zero values mean the
+// database doesn't report them: SQLite and DuckDB never record when a
+// table was analyzed, and DuckDB doesn't size single tables.
+type TableStats struct {
+	RowCount     int64
+	SizeBytes    int64
+	IndexCount   int
+	LastAnalyzed time.Time
+}
+
+// GetTableStatistics looks up the stats of tableName from the catalog of
+// the connected database. PostgreSQL's row count is the planner's estimate.
+func GetTableStatistics(tableName string, driver string) (TableStats, error) {
+	if executor == nil {
+		return TableStats{}, errors.New("not connected")
+	}
+	var stats TableStats
+	var err error
+	switch NormalizeDriver(driver) {
+	case "pgx":
+		var analyzed sql.NullTime
+		err = queryRow(`
+			SELECT GREATEST(c.reltuples, 0)::bigint, pg_total_relation_size(c.oid),
+				(SELECT count(*) FROM pg_index i WHERE i.indrelid = c.oid),
+				GREATEST(s.last_analyze, s.last_autoanalyze)
+			FROM pg_class c LEFT JOIN pg_stat_user_tables s ON s.relid = c.oid
+			WHERE c.oid = $1::regclass`, tableName,
+		).Scan(&stats.RowCount, &stats.SizeBytes, &stats.IndexCount, &analyzed)
+		stats.LastAnalyzed = analyzed.Time
+	case "sqlite":
+		// dbstat sums the pages of the table and its indexes.
+		var size sql.NullInt64
+		err = queryRow(`
+			SELECT (SELECT count(*) FROM sqlite_master WHERE type = 'index' AND tbl_name = ?1),
+				(SELECT sum(pgsize) FROM dbstat WHERE name = ?1
+					OR name IN (SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = ?1))
+			FROM sqlite_master WHERE type = 'table' AND name = ?1`, tableName,
+		).Scan(&stats.IndexCount, &size)
+		if err == nil {
+			stats.SizeBytes = size.Int64
+			err = queryRow("SELECT count(*) FROM " + sqlident.Quote(tableName)).Scan(&stats.RowCount)
+		}
+	case "duckdb":
+		where, args := duckdbTableFilter(tableName)
+		err = queryRow(
+			"SELECT estimated_size, index_count FROM duckdb_tables() WHERE "+where, args...,
+		).Scan(&stats.RowCount, &stats.IndexCount)
+	default:
+		return TableStats{}, fmt.Errorf("stats aren't supported for driver %s", driver)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return TableStats{}, fmt.Errorf("no table %s", tableName)
+	}
+	return stats, err
+}
+
+// duckdbTableFilter matches tableName, which may be qualified as
+// schema.table or database.schema.table, in duckdb_tables(). Missing parts
+// are the current database and schema, as DuckDB resolves names; dots
+// are taken as separators even inside quotes.
+func duckdbTableFilter(tableName string) (string, []interface{}) {
+	conds := []string{"database_name = current_database()", "schema_name = current_schema()", "table_name = ?"}
+	parts := strings.Split(tableName, ".")
+	if len(parts) > len(conds) {
+		parts = []string{tableName}
+	}
+	args := make([]interface{}, len(parts))
+	for i, part := range parts {
+		args[i] = part
+	}
+	switch len(parts) {
+	case 3:
+		conds[0] = "database_name = ?"
+		fallthrough
+	case 2:
+		conds[1] = "schema_name = ?"
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// row is the first row of a query run by queryRow.
+type row struct {
+	rows *sql.Rows
+	err  error
+}
+
+// queryRow runs query on the executor like sql.DB.QueryRow.
+func queryRow(query string, args ...interface{}) row {
+	rows, err := executor.Query(query, args...)
+	return row{rows: rows, err: err}
+}
+
+// Scan copies the row's columns into dest; sql.ErrNoRows when there is none.
+func (r row) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	defer r.rows.Close()
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	return r.rows.Scan(dest...)
+}
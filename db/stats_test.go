@@ -0,0 +1,52 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestGetTableStatisticsDuckDB(t *testing.T) {
+	sqlDB, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	// Two tables named t, in different schemas.
+	for _, stmt := range []string{
+		"CREATE TABLE t AS SELECT * FROM range(3)",
+		"CREATE SCHEMA s",
+		"CREATE TABLE s.t AS SELECT * FROM range(5)",
+	} {
+		if _, err := sqlDB.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	SetExecutor(NewSQLQueryExecutor(sqlDB))
+	t.Cleanup(func() { SetExecutor(nil) })
+
+	tests := []struct {
+		name string
+		want int64
+	}{
+		{"t", 3},
+		{"main.t", 3},
+		{"s.t", 5},
+		{"memory.s.t", 5},
+	}
+	for _, tt := range tests {
+		stats, err := GetTableStatistics(tt.name, "duckdb")
+		if err != nil {
+			t.Errorf("GetTableStatistics(%q): %v", tt.name, err)
+			continue
+		}
+		if stats.RowCount != tt.want {
+			t.Errorf("GetTableStatistics(%q).RowCount = %d, want %d", tt.name, stats.RowCount, tt.want)
+		}
+	}
+	for _, name := range []string{"missing", "s.missing", "other.t"} {
+		if _, err := GetTableStatistics(name, "duckdb"); err == nil {
+			t.Errorf("GetTableStatistics(%q) found a table", name)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package format
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formatter describes how a column's values are rendered.
+type Formatter struct {
+	// Type is "duration_ms", "filesize" or "percentage".
+	Type string `json:"type"`
+	// Fmt is an optional printf verb for the number, e.g. "%.2f".
+	Fmt string `json:"fmt"`
+}
+
+// Apply renders value with f. Values that aren't numbers, and unknown
+// formatter types, are returned unchanged.
+func Apply(value string, f Formatter) string {
+	n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return value
+	}
+
+	switch f.Type {
+	case "duration_ms":
+		return (time.Duration(n) * time.Millisecond).Round(time.Millisecond).String()
+	case "filesize":
+		return fileSize(n, f.Fmt)
+	case "percentage":
+		verb := f.Fmt
+		if verb == "" {
+			verb = "%.1f"
+		}
+		return fmt.Sprintf(verb, n) + "%"
+	}
+	return value
+}
+
+func fileSize(n float64, verb string) string {
+	if verb == "" {
+		verb = "%.1f"
+	}
+	units := []string{"B", "KB", "MB", "GB", "TB", "PB"}
+	i := 0
+	for n >= 1000 && i < len(units)-1 {
+		n /= 1000
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f %s", n, units[i])
+	}
+	return fmt.Sprintf(verb+" %s", n, units[i])
+}
+
+var printfVerb = regexp.MustCompile(`%[-+ #0-9.]*[a-zA-Z]`)
+
+// Number renders value with pattern, either a printf verb such as "%.2f"
+// or "%d", or a token pattern such as "#,##0.00": the zeros after the point
+// set the decimals and a comma turns on thousands separators. Text around
+// the tokens, as in "$#,##0" or "0.0%", is kept. Values that aren't numbers
+// are returned unchanged.
+func Number(value, pattern string) string {
+	n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || pattern == "" {
+		return value
+	}
+	if verb := printfVerb.FindString(pattern); verb != "" {
+		if strings.ContainsAny(verb[len(verb)-1:], "dxXob") {
+			return fmt.Sprintf(pattern, int64(n))
+		}
+		return fmt.Sprintf(pattern, n)
+	}
+
+	start := strings.IndexAny(pattern, "#0,.")
+	end := strings.LastIndexAny(pattern, "#0,.") + 1
+	if start < 0 {
+		return value
+	}
+	prefix, tokens, suffix := pattern[:start], pattern[start:end], pattern[end:]
+	decimals := 0
+	if _, frac, ok := strings.Cut(tokens, "."); ok {
+		decimals = strings.Count(frac, "0")
+	}
+	// Round halves away from zero, as spreadsheets do, not to even.
+	scale := math.Pow(10, float64(decimals))
+	s := strconv.FormatFloat(math.Round(n*scale)/scale, 'f', decimals, 64)
+	if strings.Contains(tokens, ",") {
+		s = groupThousands(s)
+	}
+	return prefix + s + suffix
+}
+
+// groupThousands puts commas between the thousands of a formatted number.
+func groupThousands(s string) string {
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	var b strings.Builder
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	if hasFrac {
+		return sign + b.String() + "." + frac
+	}
+	return sign + b.String()
+}
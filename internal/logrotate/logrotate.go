@@ -0,0 +1,102 @@
+package logrotate
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const timestampFormat = "20060102-150405.000000"
+
+type writer struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64
+	maxFiles int
+	file     *os.File
+	size     int64
+}
+
+// New opens path for appending and returns a writer that renames the file to
+// <path>.<timestamp> once it would grow past maxBytes, keeping at most
+// maxFiles rotated copies.
+func New(path string, maxBytes int64, maxFiles int) (io.WriteCloser, error) {
+	w := &writer{path: path, maxBytes: maxBytes, maxFiles: maxFiles}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *writer) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.maxBytes > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxBytes {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+func (w *writer) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	rotated := w.path + "." + time.Now().Format(timestampFormat)
+	if err := os.Rename(w.path, rotated); err != nil {
+		return err
+	}
+	if err := w.prune(); err != nil {
+		return err
+	}
+	return w.open()
+}
+
+func (w *writer) prune() error {
+	if w.maxFiles <= 0 {
+		return nil
+	}
+	rotated, err := filepath.Glob(w.path + ".*")
+	if err != nil {
+		return err
+	}
+	if len(rotated) <= w.maxFiles {
+		return nil
+	}
+	// Timestamps sort lexically, so the oldest files come first.
+	sort.Strings(rotated)
+	for _, name := range rotated[:len(rotated)-w.maxFiles] {
+		if err := os.Remove(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,372 @@
+// Package params substitutes -args values for the placeholders in a query.
+//
+// Three placeholder styles are recognized: :name, taking its value from a
+// JSON object, and $1 or ?, taking theirs from a JSON array. The style is
+// read from the query rather than the driver, so a query keeps working
+// when it's copied between engines. Values are spliced in as SQL literals,
+// as the query is later wrapped for filters and can't carry bound
+// parameters: strings are quoted, numbers, bools and null are written bare.
+package params
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Style is the kind of placeholder a query uses.
+type Style int
+
+const (
+	None       Style = iota
+	Named            // :name
+	Numbered         // $1
+	Positional       // ?
+)
+
+func (s Style) String() string {
+	switch s {
+	case Named:
+		return ":name"
+	case Numbered:
+		return "$1"
+	case Positional:
+		return "?"
+	}
+	return "none"
+}
+
+// Placeholder is one placeholder token at query[Start:End].
+type Placeholder struct {
+	Style      Style
+	Name       string // for Named
+	Index      int    // 1-based, for Numbered and Positional
+	Start, End int
+	// Quote is the quote around a Named placeholder written inside a
+	// string literal or quoted identifier, 0 for bare ones.
+	Quote byte
+}
+
+func (p Placeholder) String() string {
+	switch p.Style {
+	case Named:
+		return ":" + p.Name
+	case Numbered:
+		return "$" + strconv.Itoa(p.Index)
+	}
+	return "?"
+}
+
+// Scan returns the placeholders in query in order. Comments are skipped.
+// ? and $1 are skipped in string literals and quoted identifiers too, but
+// :name isn't, so that ':name' keeps working for string values.
+func Scan(query string) []Placeholder {
+	var found []Placeholder
+	positional := 0
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				return found
+			}
+			i += end
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return found
+			}
+			i += end + 3
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(query[i+1:], c)
+			if end < 0 {
+				end = len(query) - i - 1
+			}
+			for _, p := range scanNamed(query[i+1 : i+1+end]) {
+				p.Start, p.End = p.Start+i+1, p.End+i+1
+				p.Quote = c
+				found = append(found, p)
+			}
+			i += end + 1
+		case c == '$':
+			n := digits(query[i+1:])
+			if n > 0 {
+				index, _ := strconv.Atoi(query[i+1 : i+1+n])
+				found = append(found, Placeholder{Style: Numbered, Index: index, Start: i, End: i + 1 + n})
+				i += n
+			} else if tag, ok := dollarTag(query[i:]); ok {
+				// Dollar-quoted string: $tag$ ... $tag$.
+				end := strings.Index(query[i+len(tag):], tag)
+				if end < 0 {
+					return found
+				}
+				i += len(tag) + end + len(tag) - 1
+			}
+		case c == '?':
+			positional++
+			found = append(found, Placeholder{Style: Positional, Index: positional, Start: i, End: i + 1})
+		case c == ':':
+			if p, ok := named(query, i); ok {
+				found = append(found, p)
+				i = p.End - 1
+			} else if strings.HasPrefix(query[i:], "::") {
+				// A Postgres cast, x::type.
+				i++
+			}
+		}
+	}
+	return found
+}
+
+// scanNamed returns the :name placeholders in s, which is inside quotes.
+func scanNamed(s string) []Placeholder {
+	var found []Placeholder
+	for i := 0; i < len(s); i++ {
+		if s[i] != ':' {
+			continue
+		}
+		if p, ok := named(s, i); ok {
+			found = append(found, p)
+			i = p.End - 1
+		} else if strings.HasPrefix(s[i:], "::") {
+			i++
+		}
+	}
+	return found
+}
+
+// named reports the :name placeholder starting at s[i], if any.
+func named(s string, i int) (Placeholder, bool) {
+	if i > 0 && (s[i-1] == ':' || isIdent(s[i-1])) {
+		return Placeholder{}, false
+	}
+	end := i + 1
+	if end >= len(s) || !isIdentStart(s[end]) {
+		return Placeholder{}, false
+	}
+	for end < len(s) && isIdent(s[end]) {
+		end++
+	}
+	return Placeholder{Style: Named, Name: s[i+1 : end], Start: i, End: end}, true
+}
+
+// dollarTag returns the $tag$ opening a dollar-quoted string at s.
+func dollarTag(s string) (string, bool) {
+	end := 1
+	for end < len(s) && isIdent(s[end]) {
+		end++
+	}
+	if end < len(s) && s[end] == '$' && (end == 1 || isIdentStart(s[1])) {
+		return s[:end+1], true
+	}
+	return "", false
+}
+
+func digits(s string) int {
+	n := 0
+	for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+		n++
+	}
+	return n
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isIdent(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9'
+}
+
+// Load reads the args in the JSON file at path: an object for :name
+// placeholders or an array for $1 and ? ones. Numbers keep their JSON
+// spelling.
+func Load(path string) (any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't read file args: %s: %w", path, err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var args any
+	if err := dec.Decode(&args); err != nil {
+		return nil, fmt.Errorf("can't parse file args: %s: %w", path, err)
+	}
+	switch args.(type) {
+	case map[string]any, []any:
+		return args, nil
+	}
+	return nil, fmt.Errorf("file args %s must hold a JSON object or array", path)
+}
+
+// Substitute replaces the placeholders in query with args as loaded by
+// Load. It fails when the query mixes styles, args has the wrong shape
+// for the style found or misses a placeholder.
+func Substitute(query string, args any) (string, error) {
+	found := Scan(query)
+	if _, ok := args.(map[string]any); ok {
+		// ? is also a Postgres jsonb operator; with named args it's one.
+		found = slices.DeleteFunc(found, func(p Placeholder) bool { return p.Style == Positional })
+	}
+	style := None
+	for _, p := range found {
+		if style != None && p.Style != style {
+			return "", fmt.Errorf("query mixes %s and %s placeholders", style, p.Style)
+		}
+		style = p.Style
+	}
+
+	var named map[string]any
+	var positional []any
+	switch a := args.(type) {
+	case map[string]any:
+		named = a
+		if style == Numbered || style == Positional {
+			return "", fmt.Errorf("query uses %s placeholders, which take args as a JSON array, not an object", style)
+		}
+	case []any:
+		positional = a
+		if style == Named {
+			return "", fmt.Errorf("query uses :name placeholders, which take args as a JSON object, not an array")
+		}
+	}
+
+	if err := check(found, named, positional); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, p := range found {
+		var v any
+		if p.Style == Named {
+			v = named[p.Name]
+		} else {
+			v = positional[p.Index-1]
+		}
+		b.WriteString(query[last:p.Start])
+		b.WriteString(literal(v, p.Quote))
+		last = p.End
+	}
+	b.WriteString(query[last:])
+	return b.String(), nil
+}
+
+// literal renders v for a placeholder. Bare, strings become SQL string
+// literals with ' doubled, and numbers, bools and null are written as they
+// are; arrays and objects are quoted JSON. Inside quote, as in ':name',
+// the value is written with quote doubled instead.
+func literal(v any, quote byte) string {
+	var text string
+	switch v := v.(type) {
+	case nil:
+		if quote != 0 {
+			return ""
+		}
+		return "NULL"
+	case string:
+		text = v
+	case json.Number, bool, int, int64, float64:
+		if quote == 0 {
+			return fmt.Sprint(v)
+		}
+		text = fmt.Sprint(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			text = fmt.Sprint(v)
+		} else {
+			text = string(b)
+		}
+	}
+	if quote != 0 {
+		return strings.ReplaceAll(text, string(quote), string(quote)+string(quote))
+	}
+	return "'" + strings.ReplaceAll(text, "'", "''") + "'"
+}
+
+// check fails listing the placeholders found that args doesn't supply, so
+// a typo doesn't end up as a syntax error from the database, and logs a
+// warning for args no placeholder uses.
+func check(found []Placeholder, named map[string]any, positional []any) error {
+	var missing []string
+	seen := map[string]bool{}
+	used := make([]bool, len(positional))
+	for _, p := range found {
+		if p.Style == Named {
+			if _, ok := named[p.Name]; !ok && !seen[p.Name] {
+				missing = append(missing, p.String())
+			}
+			seen[p.Name] = true
+			continue
+		}
+		if p.Index < 1 || p.Index > len(positional) {
+			missing = append(missing, fmt.Sprintf("%s (arg %d of %d)", p, p.Index, len(positional)))
+			continue
+		}
+		used[p.Index-1] = true
+	}
+	if len(missing) > 0 {
+		return missingError(missing)
+	}
+
+	var unused []string
+	for name := range named {
+		if !seen[name] {
+			unused = append(unused, name)
+		}
+	}
+	slices.Sort(unused)
+	for i, ok := range used {
+		if !ok {
+			unused = append(unused, strconv.Itoa(i+1))
+		}
+	}
+	if len(unused) > 0 {
+		log.Printf("WARN: args not used by the query: %s", strings.Join(unused, ", "))
+	}
+	return nil
+}
+
+func missingError(missing []string) error {
+	return fmt.Errorf("args don't supply placeholders: %s", strings.Join(missing, ", "))
+}
+
+// RequireNamed fails as Substitute does when query has :name placeholders
+// that args, which may be nil, doesn't supply.
+func RequireNamed(query string, args any) error {
+	missing := Missing(query, args)
+	if len(missing) == 0 {
+		return nil
+	}
+	for i, name := range missing {
+		missing[i] = ":" + name
+	}
+	return missingError(missing)
+}
+
+// Missing returns the names of the :name placeholders in query that args,
+// which may be nil, doesn't supply, in order of first use. With array args
+// there are none to supply by name.
+func Missing(query string, args any) []string {
+	if _, ok := args.([]any); ok {
+		return nil
+	}
+	named, _ := args.(map[string]any)
+	var missing []string
+	for _, p := range Scan(query) {
+		if p.Style != Named {
+			continue
+		}
+		if _, ok := named[p.Name]; !ok && !slices.Contains(missing, p.Name) {
+			missing = append(missing, p.Name)
+		}
+	}
+	return missing
+}
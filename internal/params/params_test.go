@@ -0,0 +1,210 @@
+package params
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name, query string
+		want        []string
+	}{
+		{"named", "SELECT * FROM t WHERE a = :a AND b = :b_2", []string{":a", ":b_2"}},
+		{"numbered", "SELECT $1, $10", []string{"$1", "$10"}},
+		{"positional", "SELECT ?, ?", []string{"?", "?"}},
+		{"cast", "SELECT x::int, :a::text", []string{":a"}},
+		{"named in quotes", "SELECT ':a', \":b\"", []string{":a", ":b"}},
+		{"others in quotes", "SELECT '$1 ?', \"?\"", nil},
+		{"doubled quote", "SELECT 'it''s ?' , ?", []string{"?"}},
+		{"time literal", "SELECT '12:30:00'", nil},
+		{"line comment", "SELECT 1 -- :a ? $1\n, :b", []string{":b"}},
+		{"unterminated line comment", "SELECT :a -- :b", []string{":a"}},
+		{"block comment", "SELECT /* :a ? */ :b", []string{":b"}},
+		{"dollar quotes", "SELECT $$ :a ? $1 $$, $body$ :b $body$, :c", []string{":c"}},
+		{"no placeholders", "SELECT 1", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, p := range Scan(tt.query) {
+				if tt.query[p.Start:p.End] != p.String() {
+					t.Errorf("%s spans %q", p, tt.query[p.Start:p.End])
+				}
+				got = append(got, p.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Scan(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+// captureLog sends the log to the returned buffer for the test.
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &buf
+}
+
+func TestSubstitute(t *testing.T) {
+	// Most cases leave args unused.
+	captureLog(t)
+	named := map[string]any{
+		"id":   json.Number("3"),
+		"name": "o'neil",
+		"flag": true,
+		"none": nil,
+		"tags": []any{"a", "b"},
+	}
+	positional := []any{"a'b", json.Number("-1.5")}
+	tests := []struct {
+		name, query string
+		args        any
+		want        string
+	}{
+		{"number", "WHERE id >= :id", named, "WHERE id >= 3"},
+		{"string", "WHERE name = :name", named, "WHERE name = 'o''neil'"},
+		{"bool", "WHERE flag = :flag", named, "WHERE flag = true"},
+		{"null", "WHERE x IS :none", named, "WHERE x IS NULL"},
+		{"json", "WHERE tags = :tags", named, `WHERE tags = '["a","b"]'`},
+		{"in single quotes", "WHERE name LIKE ':name%'", named, "WHERE name LIKE 'o''neil%'"},
+		{"in double quotes", `SELECT 1 AS ":name"`, named, `SELECT 1 AS "o'neil"`},
+		// Not after an identifier character.
+		{"inside a word", "SELECT 'a:name', x:id", named, "SELECT 'a:name', x:id"},
+		{"null in quotes", "SELECT ':none'", named, "SELECT ''"},
+		{"cast", "SELECT :id::int, x::text", named, "SELECT 3::int, x::text"},
+		{"jsonb operator", "WHERE data ? 'k' AND id = :id", named, "WHERE data ? 'k' AND id = 3"},
+		{"comments", "SELECT :id -- :name\n/* :name */", named, "SELECT 3 -- :name\n/* :name */"},
+		{"dollar quotes", "SELECT $$ :name $$, :id", named, "SELECT $$ :name $$, 3"},
+		{"numbered", "SELECT $2, $1, $1", positional, "SELECT -1.5, 'a''b', 'a''b'"},
+		{"positional", "SELECT ?, '?', ?", positional, "SELECT 'a''b', '?', -1.5"},
+		{"no placeholders", "SELECT 1", named, "SELECT 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Substitute(tt.query, tt.args)
+			if err != nil {
+				t.Fatalf("Substitute(%q): %v", tt.query, err)
+			}
+			if got != tt.want {
+				t.Errorf("Substitute(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubstituteErrors(t *testing.T) {
+	tests := []struct {
+		name, query string
+		args        any
+		want        string
+	}{
+		{"missing named", "SELECT :a, :b, :a", map[string]any{}, "args don't supply placeholders: :a, :b"},
+		{"missing numbered", "SELECT $1, $3", []any{1, 2}, "args don't supply placeholders: $3 (arg 3 of 2)"},
+		{"missing positional", "SELECT ?, ?", []any{1}, "args don't supply placeholders: ? (arg 2 of 1)"},
+		{"mixed styles", "SELECT $1, ?", []any{1, 2}, "query mixes $1 and ? placeholders"},
+		{"object for numbered", "SELECT $1", map[string]any{"a": 1}, "query uses $1 placeholders, which take args as a JSON array, not an object"},
+		{"array for named", "SELECT :a", []any{1}, "query uses :name placeholders, which take args as a JSON object, not an array"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Substitute(tt.query, tt.args)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Substitute(%q) error = %v, want %q", tt.query, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubstituteUnused(t *testing.T) {
+	buf := captureLog(t)
+
+	tests := []struct {
+		name, query string
+		args        any
+		want        string
+	}{
+		{"named", "SELECT :a", map[string]any{"a": 1, "c": 2, "b": 3}, "args not used by the query: b, c"},
+		{"positional", "SELECT $2", []any{1, 2, 3}, "args not used by the query: 1, 3"},
+		{"all used", "SELECT :a", map[string]any{"a": 1}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			if _, err := Substitute(tt.query, tt.args); err != nil {
+				t.Fatalf("Substitute(%q): %v", tt.query, err)
+			}
+			got := buf.String()
+			if tt.want == "" && got != "" || !strings.Contains(got, tt.want) {
+				t.Errorf("log = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMissing(t *testing.T) {
+	query := "SELECT :a, :b, :a, $1"
+	tests := []struct {
+		name string
+		args any
+		want []string
+	}{
+		{"no args", nil, []string{"a", "b"}},
+		{"some supplied", map[string]any{"b": 1}, []string{"a"}},
+		{"all supplied", map[string]any{"a": 1, "b": 2}, nil},
+		{"array args", []any{1}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Missing(query, tt.args); !slices.Equal(got, tt.want) {
+				t.Errorf("Missing = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if err := RequireNamed(query, nil); err == nil || err.Error() != "args don't supply placeholders: :a, :b" {
+		t.Errorf("RequireNamed error = %v", err)
+	}
+	if err := RequireNamed("SELECT $1", nil); err != nil {
+		t.Errorf("RequireNamed without named placeholders: %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	args, err := Load(write("object.json", `{"n": 1.50, "s": "x"}`))
+	if err != nil {
+		t.Fatalf("Load object: %v", err)
+	}
+	// Numbers keep their JSON spelling.
+	if got := args.(map[string]any)["n"]; got != json.Number("1.50") {
+		t.Errorf("n = %#v, want json.Number 1.50", got)
+	}
+	if _, err := Load(write("array.json", `[1, "a"]`)); err != nil {
+		t.Errorf("Load array: %v", err)
+	}
+	for name, content := range map[string]string{"scalar.json": "42", "bad.json": "{"} {
+		if _, err := Load(write(name, content)); err == nil {
+			t.Errorf("Load(%s) succeeded", name)
+		}
+	}
+	if _, err := Load(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("Load of a missing file succeeded")
+	}
+}
@@ -0,0 +1,24 @@
+// Package sqlident quotes SQL identifiers the standard way, doubling the
+// double quotes inside them, which PostgreSQL, SQLite and DuckDB share.
+package sqlident
+
+import (
+	"regexp"
+	"strings"
+)
+
+var plain = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Quote double-quotes name.
+func Quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// QuoteIfNeeded leaves plain names bare, so they match however the
+// database folds their case, and quotes the others.
+func QuoteIfNeeded(name string) string {
+	if plain.MatchString(name) {
+		return name
+	}
+	return Quote(name)
+}
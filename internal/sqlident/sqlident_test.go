@@ -0,0 +1,24 @@
+package sqlident
+
+import "testing"
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		name, quoted, ifNeeded string
+	}{
+		{"users", `"users"`, "users"},
+		{"User_2", `"User_2"`, "User_2"},
+		{"order items", `"order items"`, `"order items"`},
+		{`say "hi"`, `"say ""hi"""`, `"say ""hi"""`},
+		{"naïve", `"naïve"`, `"naïve"`},
+		{"2nd", `"2nd"`, `"2nd"`},
+	}
+	for _, tt := range tests {
+		if got := Quote(tt.name); got != tt.quoted {
+			t.Errorf("Quote(%q) = %s, want %s", tt.name, got, tt.quoted)
+		}
+		if got := QuoteIfNeeded(tt.name); got != tt.ifNeeded {
+			t.Errorf("QuoteIfNeeded(%q) = %s, want %s", tt.name, got, tt.ifNeeded)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package sqlsrc
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Stdin is the -sql value that reads the query from standard input.
+const Stdin = "-"
+
+// IsSource reports whether name is an @path or - to load the query from,
+// rather than the name of a saved query.
+func IsSource(name string) bool {
+	return name == Stdin || strings.HasPrefix(name, "@")
+}
+
+// Load reads the query for an @path from the file and for - from stdin.
+func Load(name string, stdin io.Reader) (string, error) {
+	var data []byte
+	var err error
+	if name == Stdin {
+		data, err = io.ReadAll(stdin)
+		if err != nil {
+			return "", fmt.Errorf("can't read query from stdin: %w", err)
+		}
+	} else {
+		data, err = os.ReadFile(strings.TrimPrefix(name, "@"))
+		if err != nil {
+			return "", fmt.Errorf("can't read query file: %w", err)
+		}
+	}
+
+	// A trailing ; would break wrapping the query for filters.
+	query := strings.TrimRight(strings.TrimSpace(string(data)), "; \t\r\n")
+	if query == "" {
+		return "", fmt.Errorf("query from %s is empty", name)
+	}
+	return query, nil
+}
@@ -0,0 +1,159 @@
+package theme
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme holds the lipgloss colors used by the table UI.
+type Theme struct {
+	Border     string `json:"border"`
+	Header     string `json:"header"`
+	SelectedFg string `json:"selected_fg"`
+	SelectedBg string `json:"selected_bg"`
+	// Status colors the lines under the filter.
+	Status string `json:"status"`
+}
+
+var Presets = map[string]Theme{
+	"dark": {
+		Border:     "240",
+		SelectedFg: "229",
+		SelectedBg: "57",
+		Status:     "240",
+	},
+	"light": {
+		Border:     "245",
+		Header:     "235",
+		SelectedFg: "231",
+		SelectedBg: "25",
+		Status:     "244",
+	},
+	"solarized": {
+		Border:     "#586e75",
+		Header:     "#93a1a1",
+		SelectedFg: "#fdf6e3",
+		SelectedBg: "#268bd2",
+		Status:     "#657b83",
+	},
+}
+
+// Load returns the preset called name, or by default the one named by
+// $TEL_THEME. Without either it returns the dark or light preset, matching
+// the terminal background, overlaid with the JSON theme file at path, if
+// that file exists.
+func Load(name, path string) (Theme, error) {
+	if name == "" {
+		name = os.Getenv("TEL_THEME")
+	}
+	if name != "" {
+		t, ok := Presets[name]
+		if !ok {
+			return Theme{}, fmt.Errorf("unknown theme %q; available: %s", name, strings.Join(Names(), ", "))
+		}
+		return t, nil
+	}
+
+	t := Presets["dark"]
+	bg, err := DetectBackground()
+	if err != nil {
+		log.Printf("WARN: terminal background not detected, assuming dark: %v", err)
+	} else if bg == BackgroundLight {
+		t = Presets["light"]
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return t, nil
+		}
+		return Theme{}, err
+	}
+	if err := json.Unmarshal(data, &t); err != nil {
+		return Theme{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return t, nil
+}
+
+func Names() []string {
+	names := make([]string, 0, len(Presets))
+	for name := range Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TableStyles returns the bubbles table styles for the theme.
+func (t Theme) TableStyles() table.Styles {
+	s := table.DefaultStyles()
+	s.Header = s.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color(t.Border)).
+		BorderBottom(true).
+		Bold(false)
+	if t.Header != "" {
+		s.Header = s.Header.Foreground(lipgloss.Color(t.Header))
+	}
+	s.Selected = s.Selected.
+		Foreground(lipgloss.Color(t.SelectedFg)).
+		Background(lipgloss.Color(t.SelectedBg)).
+		Bold(false)
+	return s
+}
+
+// BorderStyle returns the style of the border drawn around the table.
+func (t Theme) BorderStyle() lipgloss.Style {
+	return lipgloss.NewStyle().
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color(t.Border))
+}
+
+// ApplyNoColor returns s without its colors, for NO_COLOR. Borders,
+// padding and attributes like bold and reverse video stay.
+func ApplyNoColor(s lipgloss.Style) lipgloss.Style {
+	return s.UnsetForeground().UnsetBackground().UnsetBorderForeground().UnsetBorderBackground()
+}
+
+// StatusStyle returns the style of the status lines under the filter.
+func (t Theme) StatusStyle() lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(t.Status))
+}
+
+// Background is the brightness of the terminal background.
+type Background int
+
+const (
+	BackgroundDark Background = iota
+	BackgroundLight
+)
+
+// DetectBackground reads the background from $COLORFGBG, as set by rxvt,
+// Konsole and others, and otherwise asks the terminal with an OSC 11
+// query, which reports dark when it gets no answer.
+func DetectBackground() (Background, error) {
+	if v := os.Getenv("COLORFGBG"); v != "" {
+		// "fg;bg", or "fg;default;bg" with rxvt's xpm support.
+		fields := strings.Split(v, ";")
+		bg, err := strconv.Atoi(fields[len(fields)-1])
+		if err != nil {
+			return BackgroundDark, fmt.Errorf("parse COLORFGBG=%q: %w", v, err)
+		}
+		// ANSI 7 and 9-15 are white and the bright colors.
+		if bg == 7 || bg >= 9 && bg <= 15 {
+			return BackgroundLight, nil
+		}
+		return BackgroundDark, nil
+	}
+	if lipgloss.HasDarkBackground() {
+		return BackgroundDark, nil
+	}
+	return BackgroundLight, nil
+}
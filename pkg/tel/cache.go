@@ -0,0 +1,107 @@
+package tel
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+)
+
+// resultCache holds the unfiltered rows of a query, as returned by
+// db.GetContent, so simple filters can run without a database round trip.
+type resultCache struct {
+	rows []table.Row
+	cols []table.Column
+	// types are the database types of cols, see db.GetTypedContentContext.
+	types []string
+}
+
+func newResultCache(rows []table.Row, cols []table.Column, types []string) *resultCache {
+	c := &resultCache{rows: make([]table.Row, len(rows)), cols: slices.Clone(cols), types: slices.Clone(types)}
+	for i, row := range rows {
+		c.rows[i] = slices.Clone(row)
+	}
+	return c
+}
+
+// simplePredicate matches a filter testing one column for (in)equality
+// with a string literal.
+var simplePredicate = regexp.MustCompile(`(?i)^"?([a-z_][a-z0-9_]*)"?\s*(=|!=|<>)\s*('(?:[^']|'')*')$`)
+
+// textTypes are the database types whose cells GetContent returns as the
+// database stores them, so comparing them with a string is the
+// database's comparison. Other cells are formatted, e.g. timestamps as
+// RFC 3339 and booleans as true/false, and CHAR pads with spaces.
+var textTypes = map[string]bool{
+	"TEXT":              true,
+	"VARCHAR":           true,
+	"CHARACTER VARYING": true,
+	"NAME":              true,
+}
+
+// textColumn reports whether dbType, which may carry a length such as
+// VARCHAR(20), is one of textTypes.
+func textColumn(dbType string) bool {
+	name, _, _ := strings.Cut(dbType, "(")
+	return textTypes[strings.TrimSpace(name)]
+}
+
+// filter returns copies of the rows matching filter, and ok false when it
+// can't tell how the database would evaluate it: the filter isn't a simple
+// predicate on a text column, or a cell is empty, and so possibly NULL.
+func (c *resultCache) filter(filter string) ([]table.Row, []table.Column, bool) {
+	filter = normalizeFilter(filter)
+	var match func(cell string) (bool, bool)
+	idx := -1
+	if filter != "" {
+		m := simplePredicate.FindStringSubmatch(filter)
+		if m == nil {
+			return nil, nil, false
+		}
+		idx = slices.IndexFunc(c.cols, func(col table.Column) bool { return strings.EqualFold(col.Title, m[1]) })
+		if idx < 0 || idx >= len(c.types) || !textColumn(c.types[idx]) {
+			return nil, nil, false
+		}
+		if match = cellMatcher(m[2], m[3]); match == nil {
+			return nil, nil, false
+		}
+	}
+
+	var rows []table.Row
+	for _, row := range c.rows {
+		if idx >= 0 {
+			keep, ok := match(row[idx])
+			if !ok {
+				return nil, nil, false
+			}
+			if !keep {
+				continue
+			}
+		}
+		rows = append(rows, slices.Clone(row))
+	}
+	return rows, slices.Clone(c.cols), true
+}
+
+// cellMatcher tests cells for (in)equality with the string literal using
+// op, returning nil when the database could compare them differently.
+func cellMatcher(op, literal string) func(cell string) (bool, bool) {
+	value := strings.ReplaceAll(literal[1:len(literal)-1], "''", "'")
+	// '' depends on NULLs, and numeric strings on type coercion.
+	if value == "" {
+		return nil
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return nil
+	}
+	equal := op == "="
+	return func(cell string) (bool, bool) {
+		if cell == "" {
+			// NULL = 'x' and '' = 'x' are both false, but NULL != 'x' isn't true.
+			return false, equal
+		}
+		return (cell == value) == equal, true
+	}
+}
@@ -0,0 +1,62 @@
+package tel
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/table"
+)
+
+func TestResultCacheFilter(t *testing.T) {
+	cols := columns("NAME", "CODE", "CREATED", "ACTIVE", "SCORE", "TAG")
+	types := []string{"TEXT", "VARCHAR(10)", "TIMESTAMP", "BOOL", "INT8", ""}
+	rows := []table.Row{
+		{"alice", "a1", "2024-01-01T00:00:00Z", "true", "7", "x"},
+		{"bob", "", "2024-02-01T00:00:00Z", "false", "3", "y"},
+	}
+	c := newResultCache(rows, cols, types)
+
+	tests := []struct {
+		filter string
+		want   []string // names of the rows kept; nil when the database decides
+	}{
+		{"", []string{"alice", "bob"}},
+		{"name = 'alice'", []string{"alice"}},
+		{`"NAME" <> 'alice'`, []string{"bob"}},
+		{"code = 'a1'", []string{"alice"}},
+		// An empty cell could be NULL, for which != isn't true.
+		{"code != 'a1'", nil},
+		// Formatted cells don't compare as the database's values do.
+		{"created = '2024-01-01'", nil},
+		{"active = 't'", nil},
+		{"score >= 5", nil},
+		{"score = '7'", nil},
+		// Columns of unknown type go to the database too.
+		{"tag = 'x'", nil},
+		{"name = '42'", nil},
+		{"name = ''", nil},
+		{"name LIKE 'a%'", nil},
+		{"name > 'a'", nil},
+		{"missing = 'x'", nil},
+	}
+	for _, tt := range tests {
+		got, _, ok := c.filter(tt.filter)
+		if tt.want == nil {
+			if ok {
+				t.Errorf("filter(%q) was applied in memory", tt.filter)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("filter(%q) went to the database", tt.filter)
+			continue
+		}
+		var names []string
+		for _, row := range got {
+			names = append(names, row[0])
+		}
+		if !slices.Equal(names, tt.want) {
+			t.Errorf("filter(%q) kept %v, want %v", tt.filter, names, tt.want)
+		}
+	}
+}
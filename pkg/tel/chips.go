@@ -0,0 +1,117 @@
+package tel
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"mcold/tel/internal/sqlident"
+)
+
+// filterChip is a col op value condition kept apart from the typed
+// filter, combined with the other chips by chipJoin while on. The value is
+// kept as typed and quoted when the condition is built, so it can't change
+// the SQL around it.
+type filterChip struct {
+	column, op, value string
+	on                bool
+}
+
+// cond is the chip's SQL condition. The value is always a string literal,
+// which the database coerces as for the quick filter picker's values.
+func (c filterChip) cond() string {
+	if c.op == "IS" || c.op == "IS NOT" {
+		return sqlident.QuoteIfNeeded(c.column) + " " + c.op + " NULL"
+	}
+	return sqlident.QuoteIfNeeded(c.column) + " " + c.op + " " + quoteLiteral(c.value)
+}
+
+// chipCondition matches a col op value condition, capturing the column,
+// the operator and the value as typed.
+var chipCondition = regexp.MustCompile(`(?i)^"?([a-z_][a-z0-9_]*)"?\s*(=|!=|<>|<=|>=|<|>|\s(?:not\s+)?i?like\s|\sis(?:\s+not)?\s)\s*(.*)$`)
+
+// stringLiteral matches a whole SQL string literal.
+var stringLiteral = regexp.MustCompile(`^'((?:[^']|'')*)'$`)
+
+// parseChip reads a typed condition into a chip. The value is a string
+// literal or bare text without spaces; IS and IS NOT only take NULL.
+func parseChip(cond string) (filterChip, error) {
+	match := chipCondition.FindStringSubmatch(cond)
+	if match == nil {
+		return filterChip{}, errors.New("a chip is a single col op value condition")
+	}
+	chip := filterChip{column: match[1], op: strings.ToUpper(strings.Join(strings.Fields(match[2]), " ")), on: true}
+	value := strings.TrimSpace(match[3])
+	switch {
+	case chip.op == "IS" || chip.op == "IS NOT":
+		if !strings.EqualFold(value, "NULL") {
+			return filterChip{}, fmt.Errorf("%s only takes NULL in a chip", chip.op)
+		}
+	case stringLiteral.MatchString(value):
+		chip.value = strings.ReplaceAll(stringLiteral.FindStringSubmatch(value)[1], "''", "'")
+	case value == "" || strings.ContainsAny(value, "' \t"):
+		return filterChip{}, errors.New("a chip's value is a 'quoted string' or a single word")
+	default:
+		chip.value = value
+	}
+	return chip, nil
+}
+
+// maxChips is how many chips the digit keys can toggle.
+const maxChips = 9
+
+// addChip moves the typed condition into a new chip, which must be a
+// single col op value comparison.
+func (m *Model) addChip() error {
+	cond := normalizeFilter(m.textInput.Value())
+	if cond == "" {
+		return errors.New("type a condition to add as a chip")
+	}
+	chip, err := parseChip(cond)
+	if err != nil {
+		return err
+	}
+	if len(m.chips) >= maxChips {
+		return fmt.Errorf("at most %d chips", maxChips)
+	}
+	m.chips = append(m.chips, chip)
+	m.textInput.SetValue("")
+	m.filter = ""
+	return nil
+}
+
+// withChips combines the chips that are on and the typed filter with
+// chipJoin. Without chips the typed filter is returned as it is.
+func (m Model) withChips(typed string) string {
+	var conds []string
+	for _, chip := range m.chips {
+		if chip.on {
+			conds = append(conds, chip.cond())
+		}
+	}
+	if len(conds) == 0 {
+		return typed
+	}
+	if typed := normalizeFilter(typed); typed != "" {
+		conds = append(conds, typed)
+	}
+	if len(conds) == 1 {
+		return conds[0]
+	}
+	return "(" + strings.Join(conds, ") "+m.chipJoin+" (") + ")"
+}
+
+// chipsView lists the chips, numbered for toggling, after the operator
+// joining them; chips that are off are dimmed.
+func (m Model) chipsView() string {
+	parts := []string{m.chipJoin + ":"}
+	for i, chip := range m.chips {
+		if chip.on {
+			parts = append(parts, fmt.Sprintf("[x]%d %s", i+1, chip.cond()))
+		} else {
+			parts = append(parts, statusStyle.Render(fmt.Sprintf("[ ]%d %s", i+1, chip.cond())))
+		}
+	}
+	return strings.Join(parts, "  ")
+}
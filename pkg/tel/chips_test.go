@@ -0,0 +1,64 @@
+package tel
+
+import "testing"
+
+func TestParseChip(t *testing.T) {
+	tests := []struct {
+		cond, want string
+	}{
+		{"status = 'active'", "status = 'active'"},
+		{`"STATUS"<>'o''neil'`, "STATUS <> 'o''neil'"},
+		{"status = active", "status = 'active'"},
+		{"score >= 7", "score >= '7'"},
+		{"name like 'a%'", "name LIKE 'a%'"},
+		{"name NOT  ILIKE '%x'", "name NOT ILIKE '%x'"},
+		{"name = ''", "name = ''"},
+		{"note is null", "note IS NULL"},
+		{"note IS NOT NULL", "note IS NOT NULL"},
+		// Bare values are taken as text, never as SQL.
+		{"id = 1;DROP", "id = '1;DROP'"},
+	}
+	for _, tt := range tests {
+		chip, err := parseChip(tt.cond)
+		if err != nil {
+			t.Errorf("parseChip(%q): %v", tt.cond, err)
+			continue
+		}
+		if got := chip.cond(); got != tt.want {
+			t.Errorf("parseChip(%q).cond() = %q, want %q", tt.cond, got, tt.want)
+		}
+	}
+
+	for _, cond := range []string{
+		"status",
+		"status = ",
+		"status = 'a' OR 1 = 1",
+		"status = 'unterminated",
+		"status = two words",
+		"note IS 'x'",
+		"id IN (1, 2)",
+		"lower(name) = 'a'",
+	} {
+		if chip, err := parseChip(cond); err == nil {
+			t.Errorf("parseChip(%q) = %q, want an error", cond, chip.cond())
+		}
+	}
+}
+
+func TestWithChips(t *testing.T) {
+	m := Model{chipJoin: "AND", chips: []filterChip{
+		{column: "status", op: "=", value: "active", on: true},
+		{column: "score", op: ">", value: "5", on: false},
+		{column: "name", op: "LIKE", value: "a%", on: true},
+	}}
+	if got, want := m.withChips("id = 1"), "(status = 'active') AND (name LIKE 'a%') AND (id = 1)"; got != want {
+		t.Errorf("withChips = %q, want %q", got, want)
+	}
+	m.chipJoin = "OR"
+	if got, want := m.withChips(""), "(status = 'active') OR (name LIKE 'a%')"; got != want {
+		t.Errorf("withChips = %q, want %q", got, want)
+	}
+	if got, want := (Model{}).withChips("id = 1"), "id = 1"; got != want {
+		t.Errorf("withChips without chips = %q, want %q", got, want)
+	}
+}
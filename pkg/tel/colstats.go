@@ -0,0 +1,85 @@
+package tel
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+// topValues is how many of the most frequent values the stats list.
+const topValues = 5
+
+// columnStats describes column i of rows: NULLs, which show as nullText
+// or empty cells, distinct values, min and max, compared as numbers when
+// every value is one, and the most frequent values.
+func columnStats(rows []table.Row, i int, nullText string) []string {
+	counts := map[string]int{}
+	var values []string
+	nulls := 0
+	for _, row := range rows {
+		if i >= len(row) || row[i] == "" || row[i] == nullText {
+			nulls++
+			continue
+		}
+		if counts[row[i]] == 0 {
+			values = append(values, row[i])
+		}
+		counts[row[i]]++
+	}
+
+	lines := []string{
+		fmt.Sprintf("rows      %d", len(rows)),
+		fmt.Sprintf("nulls     %d", nulls),
+		fmt.Sprintf("distinct  %d", len(values)),
+	}
+	if len(values) == 0 {
+		return lines
+	}
+
+	numbers := make(map[string]float64, len(values))
+	for _, v := range values {
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			numbers = nil
+			break
+		}
+		numbers[v] = n
+	}
+	compare := strings.Compare
+	if numbers != nil {
+		compare = func(a, b string) int { return cmp.Compare(numbers[a], numbers[b]) }
+	}
+	lines = append(lines,
+		"min       "+slices.MinFunc(values, compare),
+		"max       "+slices.MaxFunc(values, compare),
+		"",
+		"top values",
+	)
+
+	// values is in first-seen order, which breaks ties between counts.
+	slices.SortStableFunc(values, func(a, b string) int { return counts[b] - counts[a] })
+	for _, v := range values[:min(topValues, len(values))] {
+		lines = append(lines, fmt.Sprintf("%6d  %s", counts[v], v))
+	}
+	return lines
+}
+
+// colStatsView shows the stats of m.statsColumn over base, like the help.
+func (m Model) colStatsView(base string) string {
+	width, height := lipgloss.Size(base)
+	width = max(width, m.width)
+	lines := make([]string, len(m.stats))
+	for i, line := range m.stats {
+		lines[i] = runewidth.Truncate(line, max(width-6, 10), "…")
+	}
+	box := baseStyle.Padding(0, 1).Render(lipgloss.NewStyle().Bold(true).Render(m.statsColumn) + "\n\n" +
+		strings.Join(lines, "\n") + "\n\n" + statusStyle.Render("any key: close"))
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box,
+		lipgloss.WithWhitespaceChars("░"), lipgloss.WithWhitespaceForeground(helpShade))
+}
@@ -0,0 +1,95 @@
+package tel
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/table"
+)
+
+// columns returns columns titled titles, all 5 wide so a width left
+// unset shows.
+func columns(titles ...string) []table.Column {
+	cols := make([]table.Column, len(titles))
+	for i, title := range titles {
+		cols[i] = table.Column{Title: title, Width: 5}
+	}
+	return cols
+}
+
+func widthsOf(cols []table.Column) []int {
+	widths := make([]int, len(cols))
+	for i, col := range cols {
+		widths[i] = col.Width
+	}
+	return widths
+}
+
+func TestApplyColumnWidths(t *testing.T) {
+	tests := []struct {
+		name   string
+		titles []string
+		widths map[string]int
+		want   []int
+	}{
+		{"configured", []string{"ID", "NAME"}, map[string]int{"ID": 4, "NAME": 12}, []int{4, 12}},
+		{"absent defaults to 20", []string{"ID", "NAME"}, map[string]int{"ID": 4}, []int{4, 20}},
+		{"empty map", []string{"ID", "NAME"}, map[string]int{}, []int{20, 20}},
+		{"nil map", []string{"ID"}, nil, []int{20}},
+		// A zero width is kept, and hides the column.
+		{"zero overrides", []string{"ID", "NAME"}, map[string]int{"ID": 0}, []int{0, 20}},
+		// GetContent upper-cases titles, and keys must match them exactly.
+		{"keys match titles exactly", []string{"NAME"}, map[string]int{"name": 12}, []int{20}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := widthsOf(applyColumnWidths(columns(tt.titles...), tt.widths, nil, 0))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("widths = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyColumnWidthsAliases(t *testing.T) {
+	aliases := map[string]string{"NAME": "user_name", "STATUS": "user_status"}
+	tests := []struct {
+		name   string
+		widths map[string]int
+		want   []int
+	}{
+		{"width under alias", map[string]int{"user_name": 12}, []int{20, 12, 20}},
+		{"title wins over alias", map[string]int{"NAME": 8, "user_name": 12}, []int{20, 8, 20}},
+		{"mixed", map[string]int{"ID": 4, "user_status": 9}, []int{4, 20, 9}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := widthsOf(applyColumnWidths(columns("ID", "NAME", "STATUS"), tt.widths, aliases, 0))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("widths = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyColumnWidthsMaxWidth(t *testing.T) {
+	tests := []struct {
+		name     string
+		widths   map[string]int
+		maxWidth int
+		want     []int
+	}{
+		{"no cap", map[string]int{"ID": 40}, 0, []int{40, 20}},
+		{"caps configured and default widths", map[string]int{"ID": 40}, 15, []int{15, 15}},
+		{"narrower widths kept", map[string]int{"ID": 4}, 15, []int{4, 15}},
+		{"cap above every width", map[string]int{"ID": 4}, 100, []int{4, 20}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := widthsOf(applyColumnWidths(columns("ID", "NAME"), tt.widths, nil, tt.maxWidth))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("widths = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package tel
+
+import (
+	"strings"
+	"unicode"
+)
+
+// filterOperators are offered by tab after a column name in the filter.
+var filterOperators = []string{"=", "!=", "<", ">", "<=", ">=", "LIKE", "IN", "IS NULL", "IS NOT NULL"}
+
+// complete handles tab in the filter input. It completes the column name
+// before the cursor, matching names and aliases, or offers operators
+// after a column name. With several candidates it inserts the first and
+// lists them; further tabs cycle through them. It reports false when
+// there is nothing to complete.
+func (m *Model) complete() bool {
+	if len(m.suggestions) > 0 {
+		m.suggestIdx = (m.suggestIdx + 1) % len(m.suggestions)
+		m.insertSuggestion(m.suggestions[m.suggestIdx], "")
+		return true
+	}
+
+	value := []rune(m.textInput.Value())
+	pos := min(m.textInput.Position(), len(value))
+	start := pos
+	for start > 0 && isIdentRune(value[start-1]) {
+		start--
+	}
+	token := string(value[start:pos])
+
+	var candidates []string
+	if token != "" {
+		candidates = m.columnCandidates(token)
+	} else if start > 0 && value[start-1] == ' ' {
+		before := strings.Fields(string(value[:start]))
+		if len(before) > 0 && m.isColumn(before[len(before)-1]) {
+			candidates = filterOperators
+		}
+	}
+	if len(candidates) == 0 {
+		return false
+	}
+	m.suggestStart, m.suggestEnd = start, pos
+	if len(candidates) == 1 {
+		m.insertSuggestion(candidates[0], " ")
+		return true
+	}
+	m.suggestions, m.suggestIdx = candidates, 0
+	m.insertSuggestion(candidates[0], "")
+	return true
+}
+
+// insertSuggestion replaces the text being completed with s+suffix.
+func (m *Model) insertSuggestion(s, suffix string) {
+	value := []rune(m.textInput.Value())
+	inserted := []rune(s + suffix)
+	value = append(append(append([]rune{}, value[:m.suggestStart]...), inserted...), value[m.suggestEnd:]...)
+	m.suggestEnd = m.suggestStart + len(inserted)
+	m.textInput.SetValue(string(value))
+	m.textInput.SetCursor(m.suggestEnd)
+	m.filter = m.textInput.Value()
+}
+
+// columnCandidates returns the columns whose name or alias starts with
+// token, written in token's case.
+func (m Model) columnCandidates(token string) []string {
+	lower := strings.ToLower(token)
+	var candidates []string
+	for _, name := range m.columnNames() {
+		if !strings.HasPrefix(strings.ToLower(name), lower) && !strings.HasPrefix(strings.ToLower(m.aliases[name]), lower) {
+			continue
+		}
+		if strings.ToLower(token) == token {
+			name = strings.ToLower(name)
+		}
+		candidates = append(candidates, name)
+	}
+	return candidates
+}
+
+// isColumn reports whether word names a column of the result.
+func (m Model) isColumn(word string) bool {
+	word = strings.Trim(word, `"`)
+	for _, name := range m.columnNames() {
+		if strings.EqualFold(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// columnNames returns the names of the result's columns. The vertical view
+// lists them, under their aliases, in its first column.
+func (m Model) columnNames() []string {
+	var names []string
+	if m.view != "c" {
+		for _, col := range m.columns {
+			names = append(names, col.Title)
+		}
+		return names
+	}
+	original := make(map[string]string, len(m.aliases))
+	for name, alias := range m.aliases {
+		original[alias] = name
+	}
+	for _, row := range m.table.Rows() {
+		if len(row) == 0 {
+			continue
+		}
+		name := row[0]
+		if o, ok := original[name]; ok {
+			name = o
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// suggestionsView lists the candidates tab cycles through, marking the
+// one inserted.
+func (m Model) suggestionsView() string {
+	parts := make([]string, len(m.suggestions))
+	for i, s := range m.suggestions {
+		if i == m.suggestIdx {
+			s = "[" + s + "]"
+		}
+		parts[i] = s
+	}
+	return statusStyle.Render(strings.Join(parts, "  "))
+}
@@ -0,0 +1,240 @@
+package tel
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"mcold/tel/config"
+	"mcold/tel/internal/theme"
+)
+
+// DiffOptions configures a RunDiff between two snapshots of a query.
+type DiffOptions struct {
+	SQL string
+	// From and To are instance uids whose latest snapshots are compared.
+	From      string
+	To        string
+	Theme     string
+	NoColor   bool
+	ConfigDir string
+	Writer    io.Writer
+}
+
+// DiffRow is a row that differs between two snapshots. Kind is '+' for
+// added, '-' for removed and '~' for changed rows; changed cells read
+// "old → new".
+type DiffRow struct {
+	Kind  byte
+	Cells []string
+}
+
+// DiffRows compares two result sets with the same columns. Rows are matched
+// by the key columns, or by their full contents when keys is empty.
+func DiffRows(cols []string, from, to [][]string, keys []string) []DiffRow {
+	var keyIdx []int
+	for _, key := range keys {
+		for i, col := range cols {
+			if strings.EqualFold(col, key) {
+				keyIdx = append(keyIdx, i)
+			}
+		}
+	}
+	rowKey := func(row []string) string {
+		if len(keyIdx) == 0 {
+			return strings.Join(row, "\x00")
+		}
+		parts := make([]string, len(keyIdx))
+		for i, idx := range keyIdx {
+			parts[i] = row[idx]
+		}
+		return strings.Join(parts, "\x00")
+	}
+
+	before := make(map[string][]string, len(from))
+	for _, row := range from {
+		before[rowKey(row)] = row
+	}
+
+	var diff []DiffRow
+	seen := make(map[string]bool, len(to))
+	for _, row := range to {
+		k := rowKey(row)
+		seen[k] = true
+		old, ok := before[k]
+		if !ok {
+			diff = append(diff, DiffRow{Kind: '+', Cells: row})
+			continue
+		}
+		if slices.Equal(old, row) {
+			continue
+		}
+		cells := make([]string, len(row))
+		for i := range row {
+			cells[i] = row[i]
+			if old[i] != row[i] {
+				cells[i] = old[i] + " → " + row[i]
+			}
+		}
+		diff = append(diff, DiffRow{Kind: '~', Cells: cells})
+	}
+	for _, row := range from {
+		if !seen[rowKey(row)] {
+			diff = append(diff, DiffRow{Kind: '-', Cells: row})
+		}
+	}
+	return diff
+}
+
+// RunDiff shows the differences between the latest snapshots saved for two
+// instances of a query.
+func RunDiff(opts DiffOptions) error {
+	w := opts.Writer
+	if w == nil {
+		w = os.Stdout
+	}
+	if opts.SQL == "" || opts.From == "" || opts.To == "" {
+		return errors.New("diff needs sql, from and to")
+	}
+
+	if opts.ConfigDir != "" {
+		config.SetDir(opts.ConfigDir)
+	}
+	if err := config.Init(); err != nil {
+		return fmt.Errorf("config.Init failed: %w", err)
+	}
+	defer config.Close()
+	telDir, err := config.GetDir()
+	if err != nil {
+		return fmt.Errorf("config.GetDir failed: %w", err)
+	}
+	th, err := theme.Load(opts.Theme, filepath.Join(telDir, "theme.json"))
+	if err != nil {
+		return err
+	}
+
+	border := th.BorderStyle()
+	if opts.NoColor {
+		disableColor()
+		border = theme.ApplyNoColor(border)
+	}
+
+	idQuery, err := config.GetQueryID(opts.SQL)
+	if err != nil {
+		return fmt.Errorf("config.GetQueryID failed for sqlName=%s: %w", opts.SQL, err)
+	}
+	queryConfig, err := config.LoadQueryConfig(opts.SQL)
+	if err != nil {
+		return fmt.Errorf("config.LoadQueryConfig failed for sqlName=%s: %w", opts.SQL, err)
+	}
+
+	fromCols, fromRows, err := loadLatestSnapshot(idQuery, opts.From)
+	if err != nil {
+		return err
+	}
+	toCols, toRows, err := loadLatestSnapshot(idQuery, opts.To)
+	if err != nil {
+		return err
+	}
+	if !slices.Equal(fromCols, toCols) {
+		return fmt.Errorf("snapshots of %s and %s have different columns", opts.From, opts.To)
+	}
+
+	dm := diffModel{
+		cols:   toCols,
+		rows:   DiffRows(toCols, fromRows, toRows, queryConfig.Keys),
+		border: border,
+		height: 20,
+	}
+	if _, err := runProgram(tea.NewProgram(dm, tea.WithOutput(w))); err != nil {
+		return fmt.Errorf("tea.NewProgram.Run failed: %w", err)
+	}
+	return nil
+}
+
+func loadLatestSnapshot(idQuery int, uid string) ([]string, [][]string, error) {
+	name, err := config.LatestSnapshot(idQuery, uid)
+	if err != nil {
+		return nil, nil, fmt.Errorf("no snapshot for uid=%s: %w", uid, err)
+	}
+	return config.LoadSnapshot(name)
+}
+
+var diffStyles = map[byte]lipgloss.Style{
+	'+': lipgloss.NewStyle().Foreground(lipgloss.Color("2")),
+	'-': lipgloss.NewStyle().Foreground(lipgloss.Color("1")),
+	'~': lipgloss.NewStyle().Foreground(lipgloss.Color("3")),
+}
+
+// diffModel renders diff rows color-coded by kind.
+type diffModel struct {
+	cols   []string
+	rows   []DiffRow
+	border lipgloss.Style
+	offset int
+	height int
+}
+
+func (m diffModel) Init() tea.Cmd { return nil }
+
+func (m diffModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = max(msg.Height-5, 1)
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		case "down", "j":
+			if m.offset < len(m.rows)-m.height {
+				m.offset++
+			}
+		case "up", "k":
+			if m.offset > 0 {
+				m.offset--
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m diffModel) View() string {
+	widths := make([]int, len(m.cols))
+	for i, col := range m.cols {
+		widths[i] = lipgloss.Width(col)
+	}
+	for _, row := range m.rows {
+		for i, cell := range row.Cells {
+			widths[i] = min(max(widths[i], lipgloss.Width(cell)), 40)
+		}
+	}
+	line := func(cells []string) string {
+		parts := make([]string, len(cells))
+		for i, cell := range cells {
+			parts[i] = lipgloss.NewStyle().Width(widths[i]).MaxWidth(widths[i]).Inline(true).Render(cell)
+		}
+		return strings.Join(parts, "  ")
+	}
+
+	counts := map[byte]int{}
+	lines := []string{"  " + line(m.cols)}
+	end := min(m.offset+m.height, len(m.rows))
+	for _, row := range m.rows[m.offset:end] {
+		lines = append(lines, diffStyles[row.Kind].Render(string(row.Kind)+" "+line(row.Cells)))
+	}
+	for _, row := range m.rows {
+		counts[row.Kind]++
+	}
+	if len(m.rows) == 0 {
+		lines = append(lines, "  no differences")
+	}
+	summary := fmt.Sprintf("+%d -%d ~%d  (q: quit)", counts['+'], counts['-'], counts['~'])
+	return m.border.Render(strings.Join(lines, "\n")) + "\n" + summary
+}
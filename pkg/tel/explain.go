@@ -0,0 +1,64 @@
+package tel
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+
+	"mcold/tel/db"
+)
+
+// explainMsg carries the plan of the query, one line per row.
+type explainMsg struct {
+	plan []table.Row
+	err  error
+}
+
+// loadExplain returns the command fetching the plan of the query with the
+// applied filter.
+func (m Model) loadExplain() tea.Cmd {
+	query, driver := composeQuery(m.sqlQuery, m.appliedFilter), m.driver
+	return func() tea.Msg {
+		plan, _, err := db.ExplainJSON(query, driver)
+		return explainMsg{plan: plan, err: err}
+	}
+}
+
+// updateExplain scrolls the plan overlay with j/k and closes it with esc,
+// q or ctrl+x.
+func (m Model) updateExplain(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "ctrl+x":
+		m.plan, m.planOffset = nil, 0
+	case "j", "down":
+		m.planOffset = min(m.planOffset+1, max(len(m.plan)-m.planLines(), 0))
+	case "k", "up":
+		m.planOffset = max(m.planOffset-1, 0)
+	case "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// planLines is how many plan lines fit in the overlay.
+func (m Model) planLines() int {
+	return max(m.table.Height()-2, 3)
+}
+
+// explainView shows the plan lines in view over base, like the help.
+func (m Model) explainView(base string) string {
+	width, height := lipgloss.Size(base)
+	width = max(width, m.width)
+	end := min(m.planOffset+m.planLines(), len(m.plan))
+	lines := make([]string, 0, end-m.planOffset)
+	for _, row := range m.plan[m.planOffset:end] {
+		lines = append(lines, runewidth.Truncate(row[0], width-4, "…"))
+	}
+	box := baseStyle.Padding(0, 1).Render(strings.Join(lines, "\n") + "\n\n" +
+		statusStyle.Render("j/k: scroll · esc: close"))
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box,
+		lipgloss.WithWhitespaceChars("░"), lipgloss.WithWhitespaceForeground(helpShade))
+}
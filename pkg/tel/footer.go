@@ -0,0 +1,95 @@
+package tel
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+// aggregates are what a chooses from for the focused column, in order;
+// after the last one the column has none again.
+var aggregates = []string{"sum", "avg", "count"}
+
+// nextAggregate returns the aggregate following agg in aggregates.
+func nextAggregate(agg string) string {
+	for i, a := range aggregates {
+		if a == agg {
+			if i+1 < len(aggregates) {
+				return aggregates[i+1]
+			}
+			return ""
+		}
+	}
+	return aggregates[0]
+}
+
+// aggregate computes agg over column i of rows. sum and avg skip cells
+// that aren't plain numbers, such as ones a format pattern or formatter
+// rewrote, and say how many they skipped; count counts the cells that are
+// neither empty nor nullText.
+func aggregate(rows []table.Row, i int, agg, nullText string) string {
+	var sum float64
+	n, skipped := 0, 0
+	for _, row := range rows {
+		if i >= len(row) || row[i] == "" || row[i] == nullText {
+			continue
+		}
+		if agg == "count" {
+			n++
+			continue
+		}
+		if v, err := strconv.ParseFloat(strings.TrimSpace(row[i]), 64); err == nil {
+			sum += v
+			n++
+		} else {
+			skipped++
+		}
+	}
+	var value string
+	switch agg {
+	case "count":
+		return "count " + strconv.Itoa(n)
+	case "avg":
+		value = "avg -"
+		if n > 0 {
+			value = "avg " + strconv.FormatFloat(math.Round(sum/float64(n)*100)/100, 'f', -1, 64)
+		}
+	default:
+		value = "sum " + strconv.FormatFloat(sum, 'f', -1, 64)
+	}
+	if skipped > 0 {
+		value += " (" + strconv.Itoa(skipped) + " skipped)"
+	}
+	return value
+}
+
+// footerView renders the aggregates chosen for the displayed rows as a
+// line aligned with the table's columns, or "" when there are none.
+func (m Model) footerView() string {
+	if len(m.aggs) == 0 || m.view == "c" {
+		return ""
+	}
+	rows := m.table.Rows()
+	var b strings.Builder
+	for i, col := range m.table.Columns() {
+		if col.Width <= 0 {
+			continue
+		}
+		var value string
+		if i < len(m.columns) {
+			if agg := m.aggs[m.columns[i].Title]; agg != "" {
+				value = aggregate(rows, i, agg, m.nullText)
+			}
+		}
+		cell := lipgloss.NewStyle().Width(col.Width).MaxWidth(col.Width).Inline(true).
+			Render(runewidth.Truncate(value, col.Width, "…"))
+		b.WriteString(footerStyle.Render(cell))
+	}
+	return b.String()
+}
+
+var footerStyle = lipgloss.NewStyle().Padding(0, 1).Bold(true)
@@ -0,0 +1,31 @@
+package tel
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/bubbles/table"
+)
+
+func TestAggregate(t *testing.T) {
+	rows := []table.Row{{"1"}, {" 2.5 "}, {""}, {"NULL"}, {"1,234.00"}, {"1.2 GB"}}
+	tests := []struct {
+		agg  string
+		rows []table.Row
+		want string
+	}{
+		{"sum", rows[:4], "sum 3.5"},
+		{"avg", rows[:4], "avg 1.75"},
+		{"count", rows[:4], "count 2"},
+		// Formatted numbers don't parse, and are counted as skipped.
+		{"sum", rows, "sum 3.5 (2 skipped)"},
+		{"avg", rows, "avg 1.75 (2 skipped)"},
+		{"count", rows, "count 4"},
+		{"avg", rows[4:], "avg - (2 skipped)"},
+		{"sum", nil, "sum 0"},
+	}
+	for _, tt := range tests {
+		if got := aggregate(tt.rows, 0, tt.agg, "NULL"); got != tt.want {
+			t.Errorf("aggregate(%d rows, %s) = %q, want %q", len(tt.rows), tt.agg, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package tel
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"text/template"
+
+	"github.com/charmbracelet/bubbles/table"
+
+	"mcold/tel/config"
+	"mcold/tel/db"
+	"mcold/tel/internal/params"
+	"mcold/tel/internal/sqlident"
+)
+
+// HeadlessOptions configures a RunHeadless export.
+type HeadlessOptions struct {
+	// SQL names a saved query, an @path to a .sql file or - for stdin. With
+	// Query set it is only a display name.
+	SQL string
+	// Query is inline SQL run instead of a saved query.
+	Query  string
+	DB     string
+	Filter string
+	// Args is the path to a JSON file with placeholder values.
+	Args string
+	// UID restores the filter saved for an instance when Filter is empty.
+	UID string
+	// Output is "csv", "tsv" or "parquet". It is ignored when Template is
+	// set.
+	Output string
+	// OutputFile is the file parquet output is written to.
+	OutputFile string
+	// Template is the path to a text/template file that renders the rows.
+	Template string
+	// Fields selects and orders the exported columns; all when empty.
+	Fields []string
+	// Delimiter replaces the format's field separator when non-zero.
+	Delimiter rune
+	// Pipe is a shell command that receives the output on stdin instead of
+	// Writer.
+	Pipe string
+	// NoHeader skips the column title row.
+	NoHeader bool
+	// DryRun writes the composed SQL to Writer instead of running it.
+	DryRun    bool
+	ConfigDir string
+	// Writer receives the exported rows; os.Stdout when nil.
+	Writer io.Writer
+}
+
+// RunHeadless runs the query without the TUI and writes the result set to
+// the writer as CSV, TSV or through a template.
+func RunHeadless(opts HeadlessOptions) error {
+	w := opts.Writer
+	if w == nil {
+		w = os.Stdout
+	}
+
+	if opts.SQL == "" && opts.Query == "" {
+		return errors.New("sql and query are empty")
+	}
+	if opts.DB == "" {
+		return errors.New("db is empty")
+	}
+	var comma rune
+	switch {
+	case opts.Template != "":
+	case opts.Output == "csv":
+		comma = ','
+	case opts.Output == "tsv":
+		comma = '\t'
+	case opts.Output == "parquet":
+		if opts.OutputFile == "" {
+			return errors.New("parquet output needs an output file")
+		}
+	default:
+		return fmt.Errorf("unknown output format %q; use csv, tsv or parquet", opts.Output)
+	}
+	if opts.Delimiter != 0 {
+		comma = opts.Delimiter
+	}
+
+	if opts.ConfigDir != "" {
+		config.SetDir(opts.ConfigDir)
+	}
+	if err := config.Init(); err != nil {
+		return fmt.Errorf("config.Init failed: %w", err)
+	}
+	defer config.Close()
+
+	idDB, err := config.GetDBID(opts.DB)
+	if err != nil {
+		return fmt.Errorf("config.GetDBID failed for dbName=%s: %w", opts.DB, err)
+	}
+	driver, err := config.GetDBDriverByID(idDB)
+	if err != nil {
+		return fmt.Errorf("config.GetDBDriverByID failed for idDB=%d: %w", idDB, err)
+	}
+	connectionString, err := config.GetConnectionStringByID(idDB)
+	if err != nil {
+		return fmt.Errorf("config.GetConnectionStringByID failed for idDB=%d: %w", idDB, err)
+	}
+	initSQL, err := config.GetDBInitSQL(idDB)
+	if err != nil {
+		return fmt.Errorf("config.GetDBInitSQL failed for idDB=%d: %w", idDB, err)
+	}
+	sqlQuery, idQuery, queryConfig, err := loadQuery(opts.SQL, opts.Query)
+	if err != nil {
+		return err
+	}
+	if opts.Args != "" {
+		sqlQuery, err = substituteArgs(sqlQuery, opts.Args)
+		if err != nil {
+			return err
+		}
+	} else if err := params.RequireNamed(sqlQuery, nil); err != nil {
+		// There is no TUI to ask for them in.
+		return err
+	}
+
+	db.SetOptions(db.Options{
+		BinaryEncoding: queryConfig.BinaryEncoding,
+		TimeFormat:     queryConfig.TimeFormat,
+		BoolFormat:     queryConfig.BoolFormat,
+		NullText:       queryConfig.NullText,
+		EmptyText:      queryConfig.EmptyText,
+	})
+
+	filter := opts.Filter
+	if filter == "" && opts.UID != "" {
+		if filter, err = config.GetFilterByUID(opts.UID, idQuery); err != nil {
+			return fmt.Errorf("config.GetFilterByUID failed for uid=%s: %w", opts.UID, err)
+		}
+	}
+	if normalizeFilter(filter) != "" && !filterable(sqlQuery) {
+		return errNotFilterable
+	}
+	if opts.DryRun {
+		fmt.Fprintln(w, composeQuery(sqlQuery, filter))
+		return nil
+	}
+
+	if err := db.Connect(driver, connectionString, initSQL); err != nil {
+		log.Printf("ERROR: database.Connect failed for driver=%s: %v", driver, err)
+		return errors.New(db.ExplainConnectError(driver, err))
+	}
+	defer db.Close()
+
+	if opts.Output == "parquet" && opts.Template == "" {
+		return exportParquet(composeQuery(sqlQuery, filter), opts.Fields, opts.OutputFile)
+	}
+
+	rows, columns, err := db.GetContent(composeQuery(sqlQuery, filter))
+	if err != nil {
+		return fmt.Errorf("database.GetContent failed: %w", err)
+	}
+	log.Printf("Headless: %d rows, %d columns as %s", len(rows), len(columns), opts.Output)
+
+	sortRows(rows, columns, queryConfig.SortBy)
+	applyFormatters(rows, columns, queryConfig.Formatters)
+	applyNumberFormats(rows, columns, queryConfig.Format)
+	rows, columns = reorderColumns(rows, columns, queryConfig.Order)
+	if len(opts.Fields) > 0 {
+		if rows, columns, err = filterColumns(rows, columns, opts.Fields); err != nil {
+			return err
+		}
+	}
+
+	write := func(w io.Writer) error {
+		return writeDelimited(w, rows, columns, comma, opts.NoHeader)
+	}
+	if opts.Template != "" {
+		write = func(w io.Writer) error {
+			return RunTemplate(rows, columns, opts.Template, w)
+		}
+	}
+	if opts.Pipe != "" {
+		return pipeTo(opts.Pipe, w, write)
+	}
+	return write(w)
+}
+
+// exportParquet writes the fields of the result set of query, all of them
+// when fields is empty, to a Parquet file. The values are the database's,
+// without the query config's formatting.
+func exportParquet(query string, fields []string, outputPath string) error {
+	if len(fields) > 0 {
+		quoted := make([]string, len(fields))
+		for i, field := range fields {
+			quoted[i] = sqlident.QuoteIfNeeded(field)
+		}
+		query = fmt.Sprintf("SELECT %s FROM (%s)", strings.Join(quoted, ", "), query)
+	}
+	if err := db.ExportParquet(query, outputPath); err != nil {
+		return fmt.Errorf("database.ExportParquet failed: %w", err)
+	}
+	log.Printf("Headless: wrote parquet to %s", outputPath)
+	return nil
+}
+
+// writeDelimited writes rows as CSV with comma as the field separator.
+func writeDelimited(w io.Writer, rows []table.Row, cols []table.Column, comma rune, noHeader bool) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	if !noHeader {
+		titles := make([]string, len(cols))
+		for i, col := range cols {
+			titles[i] = col.Title
+		}
+		if err := cw.Write(titles); err != nil {
+			return err
+		}
+	}
+	for _, row := range rows {
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// pipeTo feeds what write produces to a shell command's stdin; the
+// command's own output goes to w.
+func pipeTo(command string, w io.Writer, write func(io.Writer) error) error {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("can't start pipe command %q: %w", command, err)
+	}
+
+	writeErr := write(stdin)
+	stdin.Close()
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("pipe command %q failed: %w", command, err)
+	}
+	return writeErr
+}
+
+// RunTemplate renders rows with the text/template at tmplPath. The template
+// gets .Columns, the column titles, and .Rows, each a map from title to value.
+func RunTemplate(rows []table.Row, cols []table.Column, tmplPath string, w io.Writer) error {
+	tmpl, err := template.ParseFiles(tmplPath)
+	if err != nil {
+		return fmt.Errorf("can't parse template %s: %w", tmplPath, err)
+	}
+
+	data := struct {
+		Rows    []map[string]string
+		Columns []string
+	}{
+		Rows:    make([]map[string]string, len(rows)),
+		Columns: make([]string, len(cols)),
+	}
+	for i, col := range cols {
+		data.Columns[i] = col.Title
+	}
+	for r, row := range rows {
+		values := make(map[string]string, len(cols))
+		for i, col := range cols {
+			values[col.Title] = row[i]
+		}
+		data.Rows[r] = values
+	}
+	return tmpl.Execute(w, data)
+}
+
+// filterColumns keeps only the named columns, in the order given. Names match
+// column titles case-insensitively.
+func filterColumns(rows []table.Row, cols []table.Column, fields []string) ([]table.Row, []table.Column, error) {
+	idx := make([]int, len(fields))
+	for i, field := range fields {
+		idx[i] = -1
+		for j, col := range cols {
+			if strings.EqualFold(col.Title, field) {
+				idx[i] = j
+				break
+			}
+		}
+		if idx[i] < 0 {
+			return nil, nil, fmt.Errorf("field %q not in result set", field)
+		}
+	}
+
+	newCols := make([]table.Column, len(idx))
+	for i, j := range idx {
+		newCols[i] = cols[j]
+	}
+	newRows := make([]table.Row, len(rows))
+	for r, row := range rows {
+		newRow := make(table.Row, len(idx))
+		for i, j := range idx {
+			newRow[i] = row[j]
+		}
+		newRows[r] = newRow
+	}
+	return newRows, newCols, nil
+}
@@ -0,0 +1,77 @@
+package tel
+
+import (
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// loadedMsg carries the table model built once the initial query returns.
+type loadedMsg struct {
+	model Model
+}
+
+// loadingModel shows a spinner while load runs the initial query, then
+// hands over to the Model it returns. A failed query is shown until a key
+// is pressed.
+type loadingModel struct {
+	spinner spinner.Model
+	load    tea.Cmd
+	// size is the last window size, passed on to the table model.
+	size *tea.WindowSizeMsg
+	err  error
+}
+
+func newLoadingModel(load func() (Model, error)) loadingModel {
+	return loadingModel{
+		spinner: spinner.New(spinner.WithSpinner(spinner.Dot), spinner.WithStyle(statusStyle)),
+		load: func() tea.Msg {
+			m, err := load()
+			if err != nil {
+				return queryErrMsg{err: err}
+			}
+			return loadedMsg{model: m}
+		},
+	}
+}
+
+func (m loadingModel) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, m.load)
+}
+
+func (m loadingModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case queryErrMsg:
+		m.err = msg.err
+		return m, nil
+	case loadedMsg:
+		var model tea.Model = msg.model
+		cmd := model.Init()
+		if m.size != nil {
+			var sizeCmd tea.Cmd
+			model, sizeCmd = model.Update(*m.size)
+			cmd = tea.Batch(cmd, sizeCmd)
+		}
+		return model, cmd
+	case tea.WindowSizeMsg:
+		m.size = &msg
+	case tea.KeyMsg:
+		if m.err != nil || msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+	case spinner.TickMsg:
+		if m.err != nil {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m loadingModel) View() string {
+	if m.err != nil {
+		return errorStyle.Render("error: "+m.err.Error()) + "\n" + statusStyle.Render("press any key to exit") + "\n"
+	}
+	return m.spinner.View() + " running query… (ctrl+c: cancel)\n"
+}
@@ -0,0 +1,1311 @@
+package tel
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+
+	"crypto/sha256"
+	"mcold/tel/config"
+	"mcold/tel/db"
+	"mcold/tel/internal/format"
+)
+
+var baseStyle = lipgloss.NewStyle().
+	BorderStyle(lipgloss.NormalBorder()).
+	BorderForeground(lipgloss.Color("240"))
+
+type Model struct {
+	table         table.Model
+	textInput     textinput.Model
+	itemName      string
+	dbName        string
+	driver        string
+	sqlName       string
+	sqlQuery      string
+	idDB          int
+	idQuery       int
+	height        int
+	maxWidth      int
+	aliases       map[string]string
+	initialFilter string
+	uid           string
+	filter        string
+	appliedFilter string
+	view          string
+	showQuery     bool
+	showHelp      bool
+	// columns are the undecorated columns currently in the table.
+	columns   []table.Column
+	colMode   bool
+	colCursor int
+	// live re-runs the filter while typing, liveFilterDelay after the last
+	// keystroke. Filters run as commands: querySeq numbers edits and
+	// queries so stale ticks and results are dropped, and cancelQuery
+	// aborts the query still running for an older one.
+	live        bool
+	querySeq    int
+	cancelQuery context.CancelFunc
+	querying    bool
+	spinner     spinner.Model
+	// err is the last filter error, shown under the filter until the next
+	// query succeeds.
+	err error
+	// cache, when set, holds the unfiltered result for client-side filtering.
+	cache *resultCache
+	// frozen columns stay on the left while the others scroll horizontally,
+	// with ←/→ or to keep the column cursor in view. width is the terminal
+	// width, 0 until known, and colOffset the first scrollable column shown.
+	// hiddenLeft, shownCols and hiddenRight count the scrollable columns
+	// left of, in and right of view.
+	frozen      []string
+	width       int
+	colOffset   int
+	hiddenLeft  int
+	shownCols   int
+	hiddenRight int
+	// searching is set while the search bar has focus; the cells containing
+	// its value stay highlighted until it's cleared with esc.
+	searching   bool
+	searchInput textinput.Model
+	// goingTo is set while ctrl+g's row number input has focus.
+	goingTo   bool
+	gotoInput textinput.Model
+	// picking is set while f's quick filter picker for pickColumn is open.
+	picking    bool
+	pickColumn string
+	pickValues []string
+	pickCursor int
+	// aggs maps column titles to the aggregate shown for them under the
+	// table, chosen with a in column mode.
+	aggs map[string]string
+	// regexMode makes the filter input a regex matched against the cells
+	// of regexCols, or all columns when it's empty, of regexBase: the rows
+	// loaded when the regex was first applied.
+	regexMode bool
+	regexCols map[string]bool
+	regexBase []table.Row
+	// plan, while set, is the EXPLAIN output ctrl+x shows over the table,
+	// scrolled down by planOffset lines.
+	plan       []table.Row
+	planOffset int
+	// stats, while set, are the lines s shows over the table for the
+	// focused column, statsColumn.
+	stats       []string
+	statsColumn string
+	// chips are conditions combined with the typed filter by chipJoin,
+	// "AND" or "OR". appliedInput is the typed part of appliedFilter.
+	chips        []filterChip
+	chipJoin     string
+	appliedInput string
+	// suggestions are the completions tab cycles through in the filter,
+	// replacing the runes from suggestStart to suggestEnd; suggestIdx is
+	// the one inserted.
+	suggestions  []string
+	suggestIdx   int
+	suggestStart int
+	suggestEnd   int
+	// queryTime is how long the query behind the current rows took; 0 means
+	// they were filtered from cache.
+	queryTime time.Duration
+	// undo holds the config as it was before each save from enter, newest
+	// last, at most undoDepth of them; redo holds what ctrl+z undid.
+	undo      []config.ConfigSnapshot
+	redo      []config.ConfigSnapshot
+	undoDepth int
+	// nullText and emptyText are how NULLs and empty strings show, from
+	// the query config.
+	nullText  string
+	emptyText string
+}
+
+const liveFilterDelay = 300 * time.Millisecond
+
+// liveFilterMsg fires when the filter input has been idle for
+// liveFilterDelay since edit seq.
+type liveFilterMsg struct{ seq int }
+
+// rowsLoadedMsg carries the rows of filter query seq. Live results don't
+// save the filter to the instance.
+type rowsLoadedMsg struct {
+	seq    int
+	filter string
+	// input is the typed part of filter, without the chips.
+	input   string
+	live    bool
+	rows    []table.Row
+	cols    []table.Column
+	elapsed time.Duration
+}
+
+// queryErrMsg reports that filter query seq failed.
+type queryErrMsg struct {
+	seq    int
+	filter string
+	live   bool
+	err    error
+}
+
+func NewModel(t table.Model, ti textinput.Model, itemName, sqlName, sqlQuery string, idDB, idQuery, height int, aliases map[string]string, initialFilter string, uid string, view string) Model {
+	m := Model{
+		table:         t,
+		textInput:     ti,
+		itemName:      itemName,
+		sqlName:       sqlName,
+		sqlQuery:      sqlQuery,
+		idDB:          idDB,
+		idQuery:       idQuery,
+		height:        height,
+		aliases:       aliases,
+		initialFilter: initialFilter,
+		uid:           uid,
+		filter:        initialFilter,
+		appliedFilter: initialFilter,
+		appliedInput:  initialFilter,
+		chipJoin:      "AND",
+		view:          view,
+		columns:       t.Columns(),
+		spinner:       spinner.New(spinner.WithSpinner(spinner.Dot), spinner.WithStyle(statusStyle)),
+		searchInput:   newSearchInput(),
+		gotoInput:     newGotoInput(),
+	}
+	m.refreshColumns()
+	return m
+}
+
+func newGotoInput() textinput.Model {
+	gi := textinput.New()
+	gi.Prompt = "go to row: "
+	gi.CharLimit = 10
+	return gi
+}
+
+func newSearchInput() textinput.Model {
+	si := textinput.New()
+	si.Prompt = "/ "
+	si.CharLimit = 200
+	return si
+}
+
+func (m Model) GetTable() table.Model {
+	return m.table
+}
+
+func (m *Model) SetTable(t table.Model) {
+	m.table = t
+	m.columns = t.Columns()
+}
+
+// setContent replaces the table contents, keeping cols undecorated in
+// m.columns while the table shows the column-mode marker.
+func (m *Model) setContent(rows []table.Row, cols []table.Column) {
+	m.columns = cols
+	m.colCursor = min(m.colCursor, max(len(cols)-1, 0))
+	m.table.SetRows(rows)
+	m.refreshColumns()
+}
+
+// refreshColumns pushes m.columns to the table under their aliases,
+// marking the focused column while in column mode.
+func (m *Model) refreshColumns() {
+	cols := db.NamedColumns(m.columns, m.aliases)
+	if name := filterColumn(m.appliedFilter); name != "" {
+		for i, col := range m.columns {
+			if strings.EqualFold(col.Title, name) || strings.EqualFold(m.aliases[col.Title], name) {
+				cols[i].Title += "*"
+			}
+		}
+	}
+	for i, col := range m.columns {
+		if m.regexCols[col.Title] {
+			cols[i].Title += "~"
+		}
+	}
+	if m.colMode && m.colCursor < len(cols) {
+		cols[m.colCursor].Title = "▸" + cols[m.colCursor].Title
+	}
+	m.scrollColumns(cols)
+	m.table.SetColumns(cols)
+}
+
+// cellPadding is the horizontal padding the table styles put around cells.
+const cellPadding = 2
+
+// scrollColumns zeroes the width of scrollable columns that don't fit the
+// terminal next to the frozen ones, which hides them, moving colOffset so
+// the column cursor stays visible.
+func (m *Model) scrollColumns(cols []table.Column) {
+	m.hiddenLeft, m.shownCols, m.hiddenRight = 0, 0, 0
+	if m.width <= 0 || m.view == "c" {
+		return
+	}
+	avail := m.width - 2 // table border
+	var scrollable []int
+	for i, col := range m.columns {
+		if slices.ContainsFunc(m.frozen, func(name string) bool { return strings.EqualFold(name, col.Title) }) {
+			avail -= col.Width + cellPadding
+		} else {
+			scrollable = append(scrollable, i)
+		}
+	}
+	if len(scrollable) == 0 {
+		return
+	}
+
+	// lastVisible is the last scrollable column that fits when showing
+	// them from offset; at least one is always shown.
+	lastVisible := func(offset int) int {
+		used := 0
+		for k := offset; k < len(scrollable); k++ {
+			used += m.columns[scrollable[k]].Width + cellPadding
+			if used > avail && k > offset {
+				return k - 1
+			}
+		}
+		return len(scrollable) - 1
+	}
+	m.colOffset = min(max(m.colOffset, 0), len(scrollable)-1)
+	if k := slices.Index(scrollable, m.colCursor); m.colMode && k >= 0 {
+		m.colOffset = min(m.colOffset, k)
+		for lastVisible(m.colOffset) < k {
+			m.colOffset++
+		}
+	}
+	// Don't scroll further right than needed to show the last column.
+	for m.colOffset > 0 && lastVisible(m.colOffset-1) == len(scrollable)-1 {
+		m.colOffset--
+	}
+
+	last := lastVisible(m.colOffset)
+	m.hiddenLeft, m.shownCols, m.hiddenRight = m.colOffset, last-m.colOffset+1, len(scrollable)-1-last
+	for k, i := range scrollable {
+		if k < m.colOffset || k > last {
+			cols[i].Width = 0
+		}
+	}
+}
+
+// filterPredicate matches a single "col op value" comparison; filterJoin
+// finds predicates combined with AND or OR.
+var (
+	filterPredicate = regexp.MustCompile(`(?i)^"?([a-z_][a-z0-9_]*)"?\s*(=|!=|<>|<=|>=|<|>|\s(not\s+)?(like|ilike|in|is)\s)`)
+	filterJoin      = regexp.MustCompile(`(?i)\s(and|or)\s`)
+)
+
+// filterColumn returns the column a simple filter such as status = 'active'
+// tests, or "" for anything more complex.
+func filterColumn(filter string) string {
+	filter = normalizeFilter(filter)
+	if filterJoin.MatchString(filter) {
+		return ""
+	}
+	match := filterPredicate.FindStringSubmatch(filter)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// moveColumn swaps the focused column with its neighbour in direction dir
+// and persists the new order to the query config.
+func (m *Model) moveColumn(dir int) {
+	i, j := m.colCursor, m.colCursor+dir
+	if j < 0 || j >= len(m.columns) {
+		return
+	}
+	m.columns[i], m.columns[j] = m.columns[j], m.columns[i]
+	// The table's rows share their cells with regexBase, so swap copies.
+	swap := func(rows []table.Row) []table.Row {
+		swapped := make([]table.Row, len(rows))
+		for k, row := range rows {
+			row = slices.Clone(row)
+			if j < len(row) {
+				row[i], row[j] = row[j], row[i]
+			}
+			swapped[k] = row
+		}
+		return swapped
+	}
+	if m.regexBase != nil {
+		m.regexBase = swap(m.regexBase)
+	}
+	m.colCursor = j
+	m.table.SetRows(swap(m.table.Rows()))
+	m.refreshColumns()
+
+	order := make([]string, len(m.columns))
+	for k, col := range m.columns {
+		order[k] = col.Title
+	}
+	if m.idQuery < 0 {
+		// Ad-hoc queries have no queries row to keep the order in.
+		return
+	}
+	if err := config.SetQueryConfigValue(m.sqlName, "order", order); err != nil {
+		log.Printf("Error saving column order: %v", err)
+	}
+}
+
+// ToVerticalView converts horizontal row to vertical column view
+func ToVerticalView(rows []table.Row, cols []table.Column) ([]table.Row, []table.Column) {
+	if len(rows) == 0 {
+		return rows, cols
+	}
+
+	// Use first row only for column view
+	row := rows[0]
+
+	verticalRows := make([]table.Row, len(cols))
+	for i := range cols {
+		colTitle := cols[i].Title
+		var value string
+		if i < len(row) {
+			value = row[i]
+		}
+		verticalRows[i] = table.Row{colTitle, value}
+	}
+
+	verticalCols := []table.Column{
+		{Title: "column", Width: 30},
+		{Title: "val", Width: 50},
+	}
+
+	return verticalRows, verticalCols
+}
+
+// sortRows sorts rows in place by the sortBy column. Cells that both parse
+// as numbers compare numerically, others as strings; empty cells go last
+// either way. Unknown columns leave rows as they are.
+func sortRows(rows []table.Row, cols []table.Column, sortBy config.SortBy) {
+	if sortBy.Column == "" {
+		return
+	}
+	idx := slices.IndexFunc(cols, func(col table.Column) bool { return strings.EqualFold(col.Title, sortBy.Column) })
+	if idx < 0 {
+		log.Printf("WARN: sort_by column %q not in result", sortBy.Column)
+		return
+	}
+	desc := strings.EqualFold(sortBy.Direction, "desc")
+	slices.SortStableFunc(rows, func(a, b table.Row) int {
+		x, y := a[idx], b[idx]
+		switch {
+		case x == "" && y == "":
+			return 0
+		case x == "":
+			return 1
+		case y == "":
+			return -1
+		}
+		c := strings.Compare(x, y)
+		if nx, err := strconv.ParseFloat(x, 64); err == nil {
+			if ny, err := strconv.ParseFloat(y, 64); err == nil {
+				c = cmp.Compare(nx, ny)
+			}
+		}
+		if desc {
+			return -c
+		}
+		return c
+	})
+}
+
+// applyNumberFormats rewrites the numbers of columns that have a format
+// pattern, see format.Number.
+func applyNumberFormats(rows []table.Row, cols []table.Column, formats map[string]string) {
+	for i, col := range cols {
+		pattern, ok := formats[strings.ToUpper(col.Title)]
+		if !ok {
+			continue
+		}
+		for _, row := range rows {
+			if i < len(row) {
+				row[i] = format.Number(row[i], pattern)
+			}
+		}
+	}
+}
+
+// applyFormatters rewrites cells of columns that have a configured formatter.
+func applyFormatters(rows []table.Row, cols []table.Column, formatters map[string]format.Formatter) {
+	if len(formatters) == 0 {
+		return
+	}
+	for i, col := range cols {
+		f, ok := formatters[strings.ToUpper(col.Title)]
+		if !ok {
+			continue
+		}
+		for _, row := range rows {
+			if i < len(row) {
+				row[i] = format.Apply(row[i], f)
+			}
+		}
+	}
+}
+
+// displayOrder is the TUI column order: frozen columns, then the saved order.
+func displayOrder(queryConfig config.QueryConfig) []string {
+	return append(slices.Clone(queryConfig.FrozenCols), queryConfig.Order...)
+}
+
+// reorderColumns moves the columns named in order to the front, in that
+// order, keeping the remaining columns in their original order.
+func reorderColumns(rows []table.Row, cols []table.Column, order []string) ([]table.Row, []table.Column) {
+	if len(order) == 0 {
+		return rows, cols
+	}
+	index := make([]int, 0, len(cols))
+	used := make([]bool, len(cols))
+	for _, name := range order {
+		for i, col := range cols {
+			if !used[i] && strings.EqualFold(col.Title, name) {
+				index = append(index, i)
+				used[i] = true
+				break
+			}
+		}
+	}
+	for i := range cols {
+		if !used[i] {
+			index = append(index, i)
+		}
+	}
+
+	newCols := make([]table.Column, len(cols))
+	for k, i := range index {
+		newCols[k] = cols[i]
+	}
+	newRows := make([]table.Row, len(rows))
+	for r, row := range rows {
+		newRow := make(table.Row, len(index))
+		for k, i := range index {
+			if i < len(row) {
+				newRow[k] = row[i]
+			}
+		}
+		newRows[r] = newRow
+	}
+	return newRows, newCols
+}
+
+func (m *Model) SelectRowByHash(targetHash string) {
+	rows := m.table.Rows()
+	for i, row := range rows {
+		hash := fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(row, "|"))))
+		if hash == targetHash {
+			m.table.SetCursor(i)
+			break
+		}
+	}
+}
+
+// normalizeFilter trims the filter and strips an optional leading WHERE.
+func normalizeFilter(filter string) string {
+	filter = strings.TrimSpace(filter)
+	filter = strings.TrimPrefix(filter, "WHERE")
+	return strings.TrimSpace(filter)
+}
+
+// composeQuery returns the SQL that runs for sqlQuery with filter applied.
+func composeQuery(sqlQuery, filter string) string {
+	filter = normalizeFilter(filter)
+	if filter == "" {
+		return sqlQuery
+	}
+	wrappedQuery := fmt.Sprintf("SELECT * FROM (%s)", sqlQuery)
+	return fmt.Sprintf("%s WHERE %s", wrappedQuery, filter)
+}
+
+// errNotFilterable is returned when a filter is applied to a query that
+// can't be used as a subquery, such as CALL or EXEC.
+var errNotFilterable = errors.New("filters only work on SELECT, WITH, VALUES, TABLE and FROM queries; this query's results can't be filtered")
+
+// filterable reports whether sqlQuery can be wrapped in SELECT * FROM (...).
+func filterable(sqlQuery string) bool {
+	q := strings.TrimSpace(sqlQuery)
+	for {
+		switch {
+		case strings.HasPrefix(q, "--"):
+			end := strings.IndexByte(q, '\n')
+			if end < 0 {
+				return false
+			}
+			q = strings.TrimSpace(q[end+1:])
+		case strings.HasPrefix(q, "/*"):
+			end := strings.Index(q, "*/")
+			if end < 0 {
+				return false
+			}
+			q = strings.TrimSpace(q[end+2:])
+		case strings.HasPrefix(q, "("):
+			q = strings.TrimSpace(q[1:])
+		default:
+			word := q
+			if end := strings.IndexFunc(q, func(r rune) bool { return !unicode.IsLetter(r) }); end >= 0 {
+				word = q[:end]
+			}
+			switch strings.ToUpper(word) {
+			case "SELECT", "WITH", "VALUES", "TABLE", "FROM":
+				return true
+			}
+			return false
+		}
+	}
+}
+
+func (m Model) FilterContent(filter string) ([]table.Row, []table.Column, error) {
+	return m.FilterContentContext(context.Background(), filter)
+}
+
+// FilterContentContext is FilterContent with a context that cancels the query.
+func (m Model) FilterContentContext(ctx context.Context, filter string) ([]table.Row, []table.Column, error) {
+	rows, cols, _, err := m.filterContent(ctx, filter)
+	return rows, cols, err
+}
+
+// filterContent does FilterContentContext, also returning how long the
+// database took, or 0 when the cached result was filtered instead.
+func (m Model) filterContent(ctx context.Context, filter string) ([]table.Row, []table.Column, time.Duration, error) {
+	if normalizeFilter(filter) != "" && !filterable(m.sqlQuery) {
+		return nil, nil, 0, errNotFilterable
+	}
+
+	queryConfig, err := config.LoadQueryConfig(m.sqlName)
+	if err != nil || m.idQuery < 0 {
+		queryConfig = config.QueryConfig{}
+	}
+	widths, aliases := queryConfig.Widths, queryConfig.Aliases
+
+	var elapsed time.Duration
+	rows, cols, ok := m.cachedContent(filter)
+	if !ok {
+		start := time.Now()
+		rows, cols, err = db.GetContentContext(ctx, composeQuery(m.sqlQuery, filter))
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		elapsed = time.Since(start)
+	}
+
+	sortRows(rows, cols, queryConfig.SortBy)
+	applyFormatters(rows, cols, queryConfig.Formatters)
+	applyNumberFormats(rows, cols, queryConfig.Format)
+
+	cols = applyColumnWidths(cols, widths, aliases, m.maxWidth)
+
+	rows, cols = reorderColumns(rows, cols, displayOrder(queryConfig))
+
+	// Convert to vertical view if view == 'c'
+	if m.view == "c" {
+		rows, cols = ToVerticalView(rows, db.NamedColumns(cols, aliases))
+	}
+
+	return rows, cols, elapsed, nil
+}
+
+// cachedContent filters the cached result, if any, reporting false when
+// filter has to go to the database.
+func (m Model) cachedContent(filter string) ([]table.Row, []table.Column, bool) {
+	if m.cache == nil {
+		return nil, nil, false
+	}
+	rows, cols, ok := m.cache.filter(filter)
+	if ok {
+		log.Printf("Filter %q applied to %d cached rows: %d match", filter, len(m.cache.rows), len(rows))
+	}
+	return rows, cols, ok
+}
+
+func (m Model) Init() tea.Cmd { return nil }
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.MouseMsg:
+		if !m.showQuery && msg.Action == tea.MouseActionPress {
+			m.handleMouse(msg)
+		}
+		return m, nil
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.refreshColumns()
+		return m, nil
+	case liveFilterMsg:
+		if msg.seq != m.querySeq {
+			return m, nil
+		}
+		return m, m.runFilter(m.textInput.Value(), true)
+	case rowsLoadedMsg:
+		if msg.seq != m.querySeq {
+			return m, nil
+		}
+		m.cancelQuery, m.querying, m.err = nil, false, nil
+		m.appliedFilter, m.appliedInput = msg.filter, msg.input
+		m.queryTime = msg.elapsed
+		m.setContent(msg.rows, msg.cols)
+		if m.regexMode {
+			m.regexBase = nil
+			m.applyRegex(m.textInput.Value())
+		}
+		if !msg.live {
+			row := m.table.SelectedRow()
+			hash := fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(row, "|"))))
+			if _, err := config.SaveInstance(m.idQuery, hash, m.uid, msg.filter); err != nil {
+				log.Printf("Error saving instance with filter: %v", err)
+			}
+		}
+		return m, nil
+	case queryErrMsg:
+		if msg.seq != m.querySeq {
+			return m, nil
+		}
+		m.cancelQuery, m.querying = nil, false
+		log.Printf("filter %q failed: %v", msg.filter, msg.err)
+		// Half-typed filters are often invalid; only enter reports errors.
+		if !msg.live {
+			m.err = msg.err
+		}
+		return m, nil
+	case spinner.TickMsg:
+		if !m.querying {
+			return m, nil
+		}
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	case pickerMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		if len(msg.values) == 0 {
+			m.err = fmt.Errorf("%s has no values to pick", msg.column)
+			return m, nil
+		}
+		m.err = nil
+		m.picking, m.pickColumn, m.pickValues, m.pickCursor = true, msg.column, msg.values, 0
+		return m, nil
+	case explainMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.err = nil
+		m.plan, m.planOffset = msg.plan, 0
+		return m, nil
+	case tea.KeyMsg:
+		if m.plan != nil {
+			return m.updateExplain(msg)
+		}
+		if m.searching {
+			return m.updateSearch(msg)
+		}
+		if m.picking {
+			return m.updatePicker(msg)
+		}
+		if m.goingTo {
+			return m.updateGoto(msg)
+		}
+		if m.showHelp {
+			m.showHelp = false
+			return m, nil
+		}
+		if m.stats != nil {
+			m.stats = nil
+			return m, nil
+		}
+		if len(m.suggestions) > 0 && msg.String() != "tab" {
+			m.suggestions = nil
+			if msg.String() == "esc" {
+				return m, nil
+			}
+		}
+		switch msg.String() {
+		case "?":
+			if m.table.Focused() {
+				m.showHelp = true
+				return m, nil
+			}
+		case "ctrl+g":
+			m.goingTo = true
+			m.gotoInput.SetValue("")
+			return m, m.gotoInput.Focus()
+		case "ctrl+/", "ctrl+_":
+			// Terminals send ctrl+/ as ctrl+_.
+			m.searching = true
+			return m, m.searchInput.Focus()
+		case "n", "N":
+			if m.table.Focused() && m.searchInput.Value() != "" {
+				m.nextMatch(map[string]int{"n": 1, "N": -1}[msg.String()])
+				return m, nil
+			}
+		case "tab":
+			if m.textInput.Focused() && !m.regexMode && m.complete() {
+				return m, nil
+			}
+			if m.table.Focused() {
+				m.table.Blur()
+				m.textInput.Focus()
+			} else {
+				m.textInput.Blur()
+				m.table.Focus()
+			}
+		case "x":
+			if m.table.Focused() {
+				m.showQuery = !m.showQuery
+				return m, nil
+			}
+		case "esc":
+			if m.showQuery {
+				m.showQuery = false
+				return m, nil
+			}
+			if m.searchInput.Value() != "" {
+				m.searchInput.SetValue("")
+				return m, nil
+			}
+			if m.colMode {
+				m.colMode = false
+				m.refreshColumns()
+				return m, nil
+			}
+			if m.table.Focused() {
+				m.table.Blur()
+			} else {
+				m.table.Focus()
+			}
+		case "ctrl+c":
+			return m, tea.Quit
+		case "h", "l":
+			if m.table.Focused() && m.view != "c" {
+				if m.colMode {
+					step := map[string]int{"h": -1, "l": 1}[msg.String()]
+					m.colCursor = min(max(m.colCursor+step, 0), len(m.columns)-1)
+				}
+				m.colMode = true
+				m.refreshColumns()
+				return m, nil
+			}
+		case "left", "right":
+			if m.table.Focused() && !m.colMode {
+				m.colOffset += map[string]int{"left": -1, "right": 1}[msg.String()]
+				m.refreshColumns()
+				return m, nil
+			}
+		case "f":
+			if m.table.Focused() && m.colMode && len(m.columns) > 0 {
+				return m, m.loadPicker(m.columns[m.colCursor].Title)
+			}
+		case "a":
+			if m.table.Focused() && m.colMode && len(m.columns) > 0 {
+				title := m.columns[m.colCursor].Title
+				if m.aggs == nil {
+					m.aggs = map[string]string{}
+				}
+				if agg := nextAggregate(m.aggs[title]); agg != "" {
+					m.aggs[title] = agg
+				} else {
+					delete(m.aggs, title)
+				}
+				return m, nil
+			}
+		case "s":
+			if m.table.Focused() && m.colMode && len(m.columns) > 0 {
+				m.statsColumn = m.columns[m.colCursor].Title
+				m.stats = columnStats(m.table.Rows(), m.colCursor, m.nullText)
+				return m, nil
+			}
+		case "ctrl+r":
+			m.toggleRegex()
+			return m, nil
+		case "r":
+			if m.table.Focused() && m.colMode && len(m.columns) > 0 {
+				title := m.columns[m.colCursor].Title
+				if m.regexCols == nil {
+					m.regexCols = map[string]bool{}
+				}
+				if m.regexCols[title] {
+					delete(m.regexCols, title)
+				} else {
+					m.regexCols[title] = true
+				}
+				m.refreshColumns()
+				if m.regexMode {
+					m.applyRegex(m.textInput.Value())
+				}
+				return m, nil
+			}
+		case "H", "L":
+			if m.table.Focused() && m.colMode {
+				m.moveColumn(map[string]int{"H": -1, "L": 1}[msg.String()])
+				return m, nil
+			}
+		case "j", "k", "g", "G", "home", "end", "ctrl+d", "ctrl+u", "pgdown", "pgup":
+			if m.table.Focused() {
+				half := max(m.table.Height()/2, 1)
+				// A page keeps one row of the previous one in view.
+				page := max(m.height-1, 1)
+				switch msg.String() {
+				case "j":
+					m.table.MoveDown(1)
+				case "k":
+					m.table.MoveUp(1)
+				case "g", "home":
+					m.table.GotoTop()
+				case "G", "end":
+					m.table.GotoBottom()
+				case "pgdown":
+					m.table.MoveDown(page)
+				case "pgup":
+					m.table.MoveUp(page)
+				case "ctrl+d":
+					m.table.MoveDown(half)
+				case "ctrl+u":
+					m.table.MoveUp(half)
+				}
+				return m, nil
+			}
+			if msg.String() == "ctrl+u" && m.textInput.Focused() && m.regexMode {
+				m.textInput.SetValue("")
+				m.applyRegex("")
+				return m, nil
+			}
+			if msg.String() == "ctrl+u" && m.textInput.Focused() {
+				// Like readline, ctrl+u clears the line; the full result
+				// set comes back without saving the empty filter.
+				m.textInput.SetValue("")
+				m.filter = ""
+				return m, m.runFilter("", true)
+			}
+		case "ctrl+z", "ctrl+y":
+			from, to := &m.undo, &m.redo
+			if msg.String() == "ctrl+y" {
+				from, to = to, from
+			}
+			if len(*from) == 0 {
+				return m, nil
+			}
+			snap := (*from)[len(*from)-1]
+			current, err := config.TakeConfigSnapshot(snap.ItemName, snap.UID)
+			if err == nil {
+				err = config.RestoreConfigSnapshot(snap)
+			}
+			if err != nil {
+				return m, tea.Printf("\nError restoring config: %v\n", err)
+			}
+			*from = (*from)[:len(*from)-1]
+			*to = pushSnapshot(*to, current, m.undoDepth)
+			log.Printf("%s: config of %s restored", msg.String(), snap.ItemName)
+			return m, nil
+		case "ctrl+x":
+			if m.table.Focused() {
+				return m, m.loadExplain()
+			}
+		case "ctrl+n":
+			if m.textInput.Focused() && !m.regexMode {
+				if err := m.addChip(); err != nil {
+					m.err = err
+					return m, nil
+				}
+				return m, m.runFilter("", false)
+			}
+		case "backspace":
+			if m.textInput.Focused() && !m.regexMode && m.textInput.Value() == "" && len(m.chips) > 0 {
+				m.chips = m.chips[:len(m.chips)-1]
+				return m, m.runFilter("", false)
+			}
+		case "ctrl+o":
+			if len(m.chips) > 0 {
+				m.chipJoin = map[string]string{"AND": "OR", "OR": "AND"}[m.chipJoin]
+				return m, m.runFilter(m.appliedInput, false)
+			}
+		case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+			if i := int(msg.String()[0] - '1'); m.table.Focused() && i < len(m.chips) {
+				m.chips[i].on = !m.chips[i].on
+				return m, m.runFilter(m.appliedInput, false)
+			}
+		case "ctrl+s":
+			if m.table.Focused() {
+				cmd := m.saveSnapshot()
+				return m, cmd
+			}
+		case "enter":
+			if m.textInput.Focused() && m.regexMode {
+				m.applyRegex(m.textInput.Value())
+				return m, nil
+			}
+			if m.textInput.Focused() {
+				// The filter is saved to the instance once its rows arrive.
+				return m, m.runFilter(m.textInput.Value(), false)
+			}
+			row := m.table.SelectedRow()
+			hash := fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(row, "|"))))
+			log.Println("RowHash: ", hash)
+			cols := m.columns
+			before, err := config.TakeConfigSnapshot(m.itemName, m.uid)
+			if err != nil {
+				log.Printf("Error taking config snapshot: %v", err)
+			}
+			if err := config.SaveConfigFromTable(m.itemName, m.idDB, m.uid, row, cols, m.aliases); err != nil {
+				return m, tea.Batch(
+					tea.Printf("\nError saving to config: %v\n", err),
+				)
+			}
+			if m.undoDepth > 0 && before.Values != nil {
+				m.undo = pushSnapshot(m.undo, before, m.undoDepth)
+				m.redo = nil
+			}
+			uid, err := config.SaveInstance(m.idQuery, hash, m.uid, m.textInput.Value())
+			if err != nil {
+				log.Printf("Error saving instance: %v", err)
+			} else {
+				log.Printf("Instance saved: uid=%s, hash=%s", uid, hash)
+			}
+			return m, tea.Batch()
+		}
+	}
+
+	m.table, cmd = m.table.Update(msg)
+	m.textInput, cmd = m.textInput.Update(msg)
+
+	// Update filter field when typing in text input
+	if m.textInput.Focused() && m.regexMode {
+		// Regexes run in memory, so they follow every keystroke.
+		m.applyRegex(m.textInput.Value())
+	} else if m.textInput.Focused() {
+		if m.live && m.textInput.Value() != m.filter {
+			m.querySeq++
+			seq := m.querySeq
+			cmd = tea.Batch(cmd, tea.Tick(liveFilterDelay, func(time.Time) tea.Msg {
+				return liveFilterMsg{seq: seq}
+			}))
+		}
+		m.filter = m.textInput.Value()
+	}
+
+	return m, cmd
+}
+
+// pushSnapshot appends snap to stack, dropping the oldest entries beyond
+// depth.
+func pushSnapshot(stack []config.ConfigSnapshot, snap config.ConfigSnapshot, depth int) []config.ConfigSnapshot {
+	stack = append(stack, snap)
+	if len(stack) > depth {
+		stack = stack[len(stack)-depth:]
+	}
+	return stack
+}
+
+// runFilter cancels the filter query still running, if any, and returns
+// the command running filter, which reports rowsLoadedMsg or queryErrMsg.
+func (m *Model) runFilter(input string, live bool) tea.Cmd {
+	filter := m.withChips(input)
+	if m.cancelQuery != nil {
+		m.cancelQuery()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelQuery = cancel
+	m.querySeq++
+	wasQuerying := m.querying
+	m.querying = true
+	mm, seq := *m, m.querySeq
+	run := func() tea.Msg {
+		defer cancel()
+		rows, cols, elapsed, err := mm.filterContent(ctx, filter)
+		if err != nil {
+			return queryErrMsg{seq: seq, filter: filter, live: live, err: err}
+		}
+		return rowsLoadedMsg{seq: seq, filter: filter, input: input, live: live, rows: rows, cols: cols, elapsed: elapsed}
+	}
+	if wasQuerying {
+		// The spinner is already ticking.
+		return run
+	}
+	return tea.Batch(run, m.spinner.Tick)
+}
+
+// updateGoto handles keys while the go-to-row input is open: enter moves
+// the cursor to the 1-based row typed, esc cancels.
+func (m Model) updateGoto(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.goingTo = false
+		m.gotoInput.Blur()
+		return m, nil
+	case tea.KeyEnter:
+		m.goingTo = false
+		m.gotoInput.Blur()
+		if n, err := strconv.Atoi(m.gotoInput.Value()); err == nil && len(m.table.Rows()) > 0 {
+			m.table.SetCursor(n - 1)
+		}
+		return m, nil
+	case tea.KeyRunes:
+		for _, r := range msg.Runes {
+			if !unicode.IsDigit(r) {
+				return m, nil
+			}
+		}
+	}
+	var cmd tea.Cmd
+	m.gotoInput, cmd = m.gotoInput.Update(msg)
+	return m, cmd
+}
+
+// handleMouse scrolls the table with the wheel and selects the clicked row.
+func (m *Model) handleMouse(msg tea.MouseMsg) {
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		m.table.MoveUp(1)
+	case tea.MouseButtonWheelDown:
+		m.table.MoveDown(1)
+	case tea.MouseButtonLeft:
+		// The table starts below the status bar, the top border line and
+		// its header.
+		header := lipgloss.Height(m.table.View()) - m.table.Height()
+		line := msg.Y - 2 - header
+		if line < 0 || line >= m.table.Height() {
+			return
+		}
+		row := firstVisibleRow(m.table) + line
+		if row >= len(m.table.Rows()) {
+			return
+		}
+		m.textInput.Blur()
+		m.table.Focus()
+		if delta := row - m.table.Cursor(); delta > 0 {
+			m.table.MoveDown(delta)
+		} else {
+			m.table.MoveUp(-delta)
+		}
+	}
+}
+
+// firstVisibleRow returns the index of the topmost row on screen. The bubbles
+// table keeps its scroll position to itself, so a copy of it, scrolled the
+// same way, is rendered plainly with each row holding its own index.
+func firstVisibleRow(t table.Model) int {
+	rows := make([]table.Row, len(t.Rows()))
+	for i := range rows {
+		rows[i] = table.Row{strconv.Itoa(i)}
+	}
+	t.SetStyles(table.Styles{})
+	// Rows first: each of their cells is rendered with a column.
+	t.SetRows(rows)
+	t.SetColumns([]table.Column{{Width: 20}})
+	lines := strings.Split(t.View(), "\n")
+	if len(lines) < 2 {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(lines[1]))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// saveSnapshot stores the displayed result set in a local table tied to the
+// instance uid, creating an instance first if there is none yet.
+func (m *Model) saveSnapshot() tea.Cmd {
+	if m.uid == "" {
+		row := m.table.SelectedRow()
+		hash := fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(row, "|"))))
+		uid, err := config.SaveInstance(m.idQuery, hash, "", m.appliedFilter)
+		if err != nil {
+			return tea.Printf("\nError saving instance: %v\n", err)
+		}
+		m.uid = uid
+	}
+
+	cols := make([]string, len(m.columns))
+	for i, col := range m.columns {
+		cols[i] = col.Title
+	}
+	rows := make([][]string, len(m.table.Rows()))
+	for i, row := range m.table.Rows() {
+		rows[i] = row
+	}
+	name, err := config.SaveSnapshot(m.sqlName, m.idQuery, m.uid, cols, rows)
+	if err != nil {
+		return tea.Printf("\nError saving snapshot: %v\n", err)
+	}
+	log.Printf("Snapshot saved: table=%s, uid=%s", name, m.uid)
+	return tea.Printf("\nSnapshot saved to %s (uid %s)\n", name, m.uid)
+}
+
+func (m Model) View() string {
+	if m.showQuery {
+		return m.queryView()
+	}
+	t := m.table
+	if term := m.searchInput.Value(); term != "" {
+		// Highlight a copy so the table's rows stay plain for row hashes.
+		highlighted := highlightSearch(t.Rows(), t.Columns(), term)
+		rows := make([]table.Row, len(highlighted))
+		for i, h := range highlighted {
+			rows[i] = h.Row
+		}
+		t.SetRows(rows)
+	}
+	tableView := t.View()
+	if footer := m.footerView(); footer != "" {
+		tableView += "\n" + footer
+	}
+	view := statusBarView(m) + "\n" + baseStyle.Render(tableView) + "\n" + m.textInput.View() + "\n"
+	if len(m.suggestions) > 1 {
+		view += m.suggestionsView() + "\n"
+	}
+	if len(m.chips) > 0 {
+		view += m.chipsView() + "\n"
+	}
+	view += m.status()
+	if m.searching {
+		view += "\n" + m.searchInput.View()
+	}
+	if m.goingTo {
+		view += "\n" + m.gotoInput.View()
+	}
+	if m.picking {
+		view += "\n" + m.pickerView()
+	}
+	if m.showHelp {
+		return m.helpView(view)
+	}
+	if m.plan != nil {
+		return m.explainView(view)
+	}
+	if m.stats != nil {
+		return m.colStatsView(view)
+	}
+	return view
+}
+
+var (
+	statusStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	errorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+)
+
+// statusBarView says which item, database and query the table shows, for
+// telling tel instances apart, e.g. in tmux panes.
+func statusBarView(m Model) string {
+	filter := m.appliedFilter
+	if filter == "" {
+		filter = "none"
+	}
+	bar := fmt.Sprintf("[item: %s] [db: %s] [sql: %s] [rows: %d] [filter: %s]",
+		m.itemName, m.dbName, m.sqlName, len(m.table.Rows()), filter)
+	if m.width > 0 {
+		bar = runewidth.Truncate(bar, m.width, "…")
+	}
+	return statusStyle.Render(bar)
+}
+
+// status reports how the current rows were fetched, followed by a
+// scrollbar when columns are scrolled out of view.
+func (m Model) status() string {
+	position := fmt.Sprintf("%d/%d  ", min(m.table.Cursor()+1, len(m.table.Rows())), len(m.table.Rows()))
+	var status string
+	switch {
+	case m.querying:
+		status = m.spinner.View() + statusStyle.Render(" running query…")
+	case m.err != nil:
+		return statusStyle.Render(position) + errorStyle.Render("error: "+m.err.Error())
+	case m.queryTime == 0:
+		status = "query: cached"
+	case m.queryTime < time.Millisecond:
+		status = "query: " + m.queryTime.Round(time.Microsecond).String()
+	default:
+		status = "query: " + m.queryTime.Round(time.Millisecond).String()
+	}
+	if m.hiddenLeft > 0 || m.hiddenRight > 0 {
+		status += "  " + m.scrollbar(20)
+	}
+	if search := m.searchStatus(); search != "" {
+		status += "  " + search
+	}
+	return statusStyle.Render(position + status)
+}
+
+// scrollbar draws the visible share of the scrollable columns as a thumb
+// in a track width cells wide.
+func (m Model) scrollbar(width int) string {
+	scrollable := m.hiddenLeft + m.shownCols + m.hiddenRight
+	start := m.hiddenLeft * width / scrollable
+	thumb := max(m.shownCols*width/scrollable, 1)
+	if m.hiddenRight == 0 {
+		start = width - thumb
+	}
+	thumb = min(thumb, width-start)
+	return "◂" + strings.Repeat("─", start) + strings.Repeat("━", thumb) + strings.Repeat("─", width-start-thumb) + "▸"
+}
+
+// queryView shows the SQL currently driving the table.
+func (m Model) queryView() string {
+	query := composeQuery(m.sqlQuery, m.appliedFilter)
+	return baseStyle.Padding(0, 1).Render(query) + "\n" + "x/esc: close"
+}
+
+// helpKeys lists the bindings for the ? overlay.
+var helpKeys = [][2]string{
+	{"tab", "toggle focus between table and filter"},
+	{"tab (filter)", "complete a column name or operator"},
+	{"enter", "apply filter / save row and filter"},
+	{"esc", "leave column mode or popup / toggle focus"},
+	{"j/k", "move down / up"},
+	{"g/G, home/end", "first / last row"},
+	{"pgdn/pgup", "next / previous page"},
+	{"ctrl+d/ctrl+u", "half page down / up"},
+	{"ctrl+u", "clear the filter (filter focused)"},
+	{"ctrl+n", "add the typed condition as a chip"},
+	{"1-9", "toggle a chip"},
+	{"ctrl+o", "join chips with AND / OR"},
+	{"backspace", "remove the last chip (empty filter)"},
+	{"ctrl+g", "go to row"},
+	{"ctrl+/", "search; n/N next / previous match"},
+	{"←/→", "scroll columns"},
+	{"h/l", "column mode / previous, next column"},
+	{"H/L", "move the focused column"},
+	{"f", "quick filter on the focused column"},
+	{"a", "cycle sum / avg / count of the focused column"},
+	{"s", "stats of the focused column"},
+	{"ctrl+r", "toggle regex filter mode"},
+	{"r", "toggle the focused column for the regex"},
+	{"x", "show the SQL"},
+	{"ctrl+x", "show the query plan"},
+	{"ctrl+s", "snapshot the rows"},
+	{"ctrl+z/ctrl+y", "undo / redo config save"},
+	{"?", "toggle this help"},
+	{"ctrl+c", "quit"},
+}
+
+// helpView centers the key bindings over the area the table takes, filled
+// with a dim pattern so the table seems to show through.
+func (m Model) helpView(base string) string {
+	var b strings.Builder
+	for i, k := range helpKeys {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(lipgloss.NewStyle().Bold(true).Width(16).Render(k[0]) + k[1])
+	}
+	box := baseStyle.Padding(0, 1).Render(b.String() + "\n\n" + statusStyle.Render("any key: close"))
+	width, height := lipgloss.Size(base)
+	return lipgloss.Place(max(width, m.width), height, lipgloss.Center, lipgloss.Center, box,
+		lipgloss.WithWhitespaceChars("░"), lipgloss.WithWhitespaceForeground(helpShade))
+}
+
+// helpShade colors the pattern around the help box.
+var helpShade lipgloss.TerminalColor = lipgloss.Color("236")
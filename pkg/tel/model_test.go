@@ -0,0 +1,66 @@
+package tel
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+)
+
+func TestFirstVisibleRow(t *testing.T) {
+	rows := make([]table.Row, 50)
+	for i := range rows {
+		rows[i] = table.Row{"row " + strconv.Itoa(i), "x"}
+	}
+	tbl := table.New(
+		table.WithColumns([]table.Column{{Title: "NAME", Width: 8}, {Title: "X", Width: 3}}),
+		table.WithRows(rows),
+		table.WithFocused(true),
+		// Plain styles put the first row on the line under the header.
+		table.WithStyles(table.Styles{}),
+		table.WithHeight(6),
+	)
+	moves := []func(){
+		func() {},
+		func() { tbl.MoveDown(3) },
+		func() { tbl.MoveDown(10) },
+		func() { tbl.MoveUp(2) },
+		func() { tbl.SetCursor(40) },
+		func() { tbl.MoveUp(20) },
+		func() { tbl.GotoBottom() },
+		func() { tbl.GotoTop() },
+	}
+	for i, move := range moves {
+		move()
+		top := strings.Split(tbl.View(), "\n")[1]
+		want, _ := strconv.Atoi(strings.Fields(top)[1])
+		if got := firstVisibleRow(tbl); got != want {
+			t.Errorf("move %d, cursor %d: firstVisibleRow = %d, want %d (%q on screen)", i, tbl.Cursor(), got, want, top)
+		}
+	}
+}
+
+func TestMoveColumnWithRegex(t *testing.T) {
+	cols := []table.Column{{Title: "NAME", Width: 8}, {Title: "STATUS", Width: 8}}
+	rows := []table.Row{{"alice", "active"}, {"bob", "inactive"}, {"carol", "active"}}
+	tbl := table.New(table.WithColumns(cols), table.WithRows(rows))
+	// An ad-hoc query, so the new order isn't saved.
+	m := NewModel(tbl, textinput.New(), "", "", "SELECT 1", 0, -1, 10, nil, "", "", "r")
+
+	m.applyRegex("^bob$")
+	m.moveColumn(1)
+	if got, want := m.table.Rows(), []table.Row{{"inactive", "bob"}}; !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("filtered rows = %q, want %q", got, want)
+	}
+
+	// Leaving regex mode brings back every row, in the new column order.
+	m.regexMode = true
+	m.toggleRegex()
+	want := []table.Row{{"active", "alice"}, {"inactive", "bob"}, {"active", "carol"}}
+	if got := m.table.Rows(); !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("rows after regex mode = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,113 @@
+package tel
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"mcold/tel/db"
+	"mcold/tel/internal/sqlident"
+)
+
+// pickerLimit is the most distinct values a column may have for f to
+// offer them as quick filters.
+const pickerLimit = 20
+
+// pickerMsg carries the distinct values of column, or why there are none
+// to pick from.
+type pickerMsg struct {
+	column string
+	values []string
+	err    error
+}
+
+// loadPicker returns the command fetching up to pickerLimit distinct
+// values of column from the unfiltered query.
+func (m Model) loadPicker(column string) tea.Cmd {
+	query := fmt.Sprintf("SELECT DISTINCT %s FROM (%s) ORDER BY 1 LIMIT %d", sqlident.QuoteIfNeeded(column), m.sqlQuery, pickerLimit+1)
+	return func() tea.Msg {
+		rows, _, err := db.GetContentContext(context.Background(), query)
+		if err != nil {
+			return pickerMsg{column: column, err: err}
+		}
+		if len(rows) > pickerLimit {
+			return pickerMsg{column: column, err: fmt.Errorf("%s has more than %d distinct values; type a filter instead", column, pickerLimit)}
+		}
+		values := make([]string, len(rows))
+		for i, row := range rows {
+			values[i] = row[0]
+		}
+		return pickerMsg{column: column, values: values}
+	}
+}
+
+// updatePicker handles keys while the quick filter picker is open: enter
+// applies the filter for the value under the cursor, esc closes it.
+func (m Model) updatePicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "f":
+		m.picking = false
+	case "j", "down":
+		m.pickCursor = max(min(m.pickCursor+1, len(m.pickValues)-1), 0)
+	case "k", "up":
+		m.pickCursor = max(m.pickCursor-1, 0)
+	case "enter":
+		m.picking = false
+		if len(m.pickValues) == 0 {
+			return m, nil
+		}
+		filter := equalsFilter(m.pickColumn, m.pickValues[m.pickCursor], m.nullText, m.emptyText)
+		m.textInput.SetValue(filter)
+		m.filter = filter
+		// Saved to the instance once its rows arrive, like a typed filter.
+		return m, m.runFilter(filter, false)
+	case "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// pickerView lists the values to pick from, marking the one under the
+// cursor.
+func (m Model) pickerView() string {
+	var b strings.Builder
+	b.WriteString(statusStyle.Render(fmt.Sprintf("filter %s = (enter: apply, esc: cancel)", m.pickColumn)))
+	for i, v := range m.pickValues {
+		if v == "" && m.nullText == "" {
+			v = "NULL"
+		}
+		cursor := "  "
+		if i == m.pickCursor {
+			cursor = "> "
+		}
+		b.WriteString("\n" + cursor + v)
+	}
+	return b.String()
+}
+
+var number = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// equalsFilter composes the filter matching column to value as shown in
+// the table, where NULL shows as nullText and empty strings as emptyText.
+// By default both are empty cells, taken for NULL. Other values, numbers
+// too, are string literals: Postgres and DuckDB coerce them to the
+// column's type and SQLite applies the column's affinity, where a bare 42
+// would fail against a Postgres text column.
+func equalsFilter(column, value, nullText, emptyText string) string {
+	column = sqlident.QuoteIfNeeded(column)
+	switch value {
+	case nullText:
+		return column + " IS NULL"
+	case emptyText:
+		return column + " = ''"
+	}
+	return column + " = " + quoteLiteral(value)
+}
+
+// quoteLiteral returns value as an SQL string literal.
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
@@ -0,0 +1,43 @@
+package tel
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestUpdatePickerEmpty(t *testing.T) {
+	m := Model{picking: true, pickColumn: "STATUS"}
+	j := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")}
+	k := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("k")}
+	for _, msg := range []tea.KeyMsg{j, k, j, {Type: tea.KeyEnter}} {
+		next, _ := m.updatePicker(msg)
+		m = next.(Model)
+		if m.pickCursor != 0 {
+			t.Fatalf("after %s: pickCursor = %d, want 0", msg, m.pickCursor)
+		}
+	}
+	if m.picking {
+		t.Error("enter left the empty picker open")
+	}
+}
+
+func TestEqualsFilter(t *testing.T) {
+	tests := []struct {
+		value, nullText, emptyText, want string
+	}{
+		{"", "", "", "STATUS IS NULL"},
+		{"active", "", "", "STATUS = 'active'"},
+		{"o'neil", "", "", "STATUS = 'o''neil'"},
+		{"42", "", "", "STATUS = '42'"},
+		{"007", "", "", "STATUS = '007'"},
+		{"true", "", "", "STATUS = 'true'"},
+		{"∅", "∅", "—", "STATUS IS NULL"},
+		{"—", "∅", "—", "STATUS = ''"},
+	}
+	for _, tt := range tests {
+		if got := equalsFilter("STATUS", tt.value, tt.nullText, tt.emptyText); got != tt.want {
+			t.Errorf("equalsFilter(%q, %q, %q) = %q, want %q", tt.value, tt.nullText, tt.emptyText, got, tt.want)
+		}
+	}
+}
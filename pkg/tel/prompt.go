@@ -0,0 +1,107 @@
+package tel
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// promptModel asks for the :name placeholders that -args didn't supply,
+// one input each, and hands over to the model submit returns for the
+// values typed. Numbers are passed as numbers and anything else as a
+// string, which params quotes as a literal.
+type promptModel struct {
+	names  []string
+	inputs []textinput.Model
+	focus  int
+	submit func(values map[string]any) (tea.Model, error)
+	// size is the last window size, passed on to the next model.
+	size *tea.WindowSizeMsg
+	err  error
+}
+
+func newPromptModel(names []string, submit func(values map[string]any) (tea.Model, error)) promptModel {
+	inputs := make([]textinput.Model, len(names))
+	for i, name := range names {
+		inputs[i] = textinput.New()
+		inputs[i].Prompt = ":" + name + " = "
+		inputs[i].CharLimit = 500
+	}
+	inputs[0].Focus()
+	return promptModel{names: names, inputs: inputs, submit: submit}
+}
+
+func (m promptModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m promptModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.size = &msg
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			return m, tea.Quit
+		case "tab", "down":
+			return m, m.setFocus(m.focus + 1)
+		case "shift+tab", "up":
+			return m, m.setFocus(m.focus - 1)
+		case "enter":
+			if m.focus < len(m.inputs)-1 {
+				return m, m.setFocus(m.focus + 1)
+			}
+			values := make(map[string]any, len(m.names))
+			for i, name := range m.names {
+				values[name] = promptValue(m.inputs[i].Value())
+			}
+			next, err := m.submit(values)
+			if err != nil {
+				m.err = err
+				return m, nil
+			}
+			cmd := next.Init()
+			if m.size != nil {
+				var sizeCmd tea.Cmd
+				next, sizeCmd = next.Update(*m.size)
+				cmd = tea.Batch(cmd, sizeCmd)
+			}
+			return next, cmd
+		}
+	}
+	var cmd tea.Cmd
+	m.inputs[m.focus], cmd = m.inputs[m.focus].Update(msg)
+	return m, cmd
+}
+
+// promptValue is the arg for text typed at the prompt: a json.Number when
+// it's a plain number, so :id stays numeric, or else the text itself.
+func promptValue(text string) any {
+	if number.MatchString(text) {
+		return json.Number(text)
+	}
+	return text
+}
+
+// setFocus moves the focus to input i, wrapping around.
+func (m *promptModel) setFocus(i int) tea.Cmd {
+	m.inputs[m.focus].Blur()
+	m.focus = (i + len(m.inputs)) % len(m.inputs)
+	return m.inputs[m.focus].Focus()
+}
+
+func (m promptModel) View() string {
+	var b strings.Builder
+	b.WriteString("Query parameters:\n")
+	for _, input := range m.inputs {
+		b.WriteString(input.View() + "\n")
+	}
+	if m.err != nil {
+		b.WriteString(errorStyle.Render("error: "+m.err.Error()) + "\n")
+	}
+	b.WriteString(statusStyle.Render("enter: next / run · tab: next field · esc: quit") + "\n")
+	return b.String()
+}
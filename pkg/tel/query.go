@@ -0,0 +1,54 @@
+package tel
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"os"
+
+	"mcold/tel/config"
+	"mcold/tel/internal/sqlsrc"
+)
+
+// adHocQueryID derives a stable, negative query id from key so instances and
+// snapshots of queries that aren't in the queries table still have one.
+func adHocQueryID(key string) int {
+	sum := sha256.Sum256([]byte(key))
+	return -int(binary.BigEndian.Uint32(sum[:4])>>1) - 1
+}
+
+// loadQuery returns the SQL, query id and config for sqlName, or for the
+// inline query when one is given. Inline queries and those read from an
+// @path or stdin aren't in the queries table, so they get an adHocQueryID and an
+// empty config.
+func loadQuery(sqlName, inline string) (string, int, config.QueryConfig, error) {
+	if inline != "" {
+		return inline, adHocQueryID(inline), config.QueryConfig{}, nil
+	}
+	if sqlsrc.IsSource(sqlName) {
+		sqlQuery, err := sqlsrc.Load(sqlName, os.Stdin)
+		if err != nil {
+			return "", 0, config.QueryConfig{}, err
+		}
+		// Files are keyed by path so edits keep their sessions.
+		key := sqlName
+		if sqlName == sqlsrc.Stdin {
+			key = sqlQuery
+		}
+		return sqlQuery, adHocQueryID(key), config.QueryConfig{}, nil
+	}
+
+	idQuery, err := config.GetQueryID(sqlName)
+	if err != nil {
+		return "", 0, config.QueryConfig{}, fmt.Errorf("config.GetQueryID failed for sqlName=%s: %w", sqlName, err)
+	}
+	sqlQuery, err := config.GetQueryFromDB(sqlName)
+	if err != nil {
+		return "", 0, config.QueryConfig{}, fmt.Errorf("config.GetQueryFromDB failed for sqlName=%s: %w", sqlName, err)
+	}
+	queryConfig, err := config.LoadQueryConfig(sqlName)
+	if err != nil {
+		return "", 0, config.QueryConfig{}, fmt.Errorf("config.LoadQueryConfig failed for sqlName=%s: %w", sqlName, err)
+	}
+	return sqlQuery, idQuery, queryConfig, nil
+}
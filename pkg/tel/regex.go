@@ -0,0 +1,54 @@
+package tel
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/charmbracelet/bubbles/table"
+)
+
+// toggleRegex switches the filter input between SQL filters and regexes.
+// Leaving regex mode brings back the rows the regex filtered and the SQL
+// filter typed for them.
+func (m *Model) toggleRegex() {
+	m.regexMode = !m.regexMode
+	if m.regexMode {
+		m.textInput.Prompt = "re> "
+		m.textInput.SetValue("")
+		return
+	}
+	m.textInput.Prompt = "> "
+	if m.regexBase != nil {
+		m.table.SetRows(m.regexBase)
+		m.regexBase = nil
+	}
+	m.err = nil
+	m.textInput.SetValue(m.appliedInput)
+	m.filter = m.appliedInput
+}
+
+// applyRegex keeps the rows loaded when regex mode was entered that have
+// a cell matching pattern, looking only at regexCols when any are set. An
+// invalid pattern is reported on the status line and leaves the rows.
+func (m *Model) applyRegex(pattern string) {
+	if m.regexBase == nil {
+		m.regexBase = m.table.Rows()
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		m.err = fmt.Errorf("regex: %w", err)
+		return
+	}
+	m.err = nil
+	var rows []table.Row
+	for _, row := range m.regexBase {
+		for i, cell := range row {
+			if i < len(m.columns) && (len(m.regexCols) == 0 || m.regexCols[m.columns[i].Title]) && re.MatchString(cell) {
+				rows = append(rows, row)
+				break
+			}
+		}
+	}
+	m.table.SetRows(rows)
+	m.table.GotoTop()
+}
@@ -0,0 +1,73 @@
+package tel
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"mcold/tel/config"
+)
+
+// ReportOptions configures a RunReport.
+type ReportOptions struct {
+	Name string
+	// Args is the path to a JSON file with the placeholder values every
+	// query of the report binds.
+	Args string
+	// Output is "csv" or "tsv".
+	Output    string
+	NoHeader  bool
+	ConfigDir string
+	// Writer receives the results; os.Stdout when nil.
+	Writer io.Writer
+}
+
+// RunReport runs the queries of a saved report one after another with
+// the same args, writing their results stacked: each under a "# name"
+// line, separated by a blank line.
+func RunReport(opts ReportOptions) error {
+	w := opts.Writer
+	if w == nil {
+		w = os.Stdout
+	}
+	if opts.Name == "" {
+		return errors.New("report is empty")
+	}
+
+	if opts.ConfigDir != "" {
+		config.SetDir(opts.ConfigDir)
+	}
+	if err := config.Init(); err != nil {
+		return fmt.Errorf("config.Init failed: %w", err)
+	}
+	report, err := config.GetReport(opts.Name)
+	config.Close()
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("no report %s", opts.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("config.GetReport failed for name=%s: %w", opts.Name, err)
+	}
+
+	for i, query := range report.Queries {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "# %s\n", query)
+		err := RunHeadless(HeadlessOptions{
+			SQL:       query,
+			DB:        report.DB,
+			Args:      opts.Args,
+			Output:    opts.Output,
+			NoHeader:  opts.NoHeader,
+			ConfigDir: opts.ConfigDir,
+			Writer:    w,
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", query, err)
+		}
+	}
+	return nil
+}
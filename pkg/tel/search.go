@@ -0,0 +1,126 @@
+package tel
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+)
+
+// Reverse video on and off. Unlike a lipgloss style's full reset, turning
+// off only reverse keeps the selected row's colors for the cells after a
+// match.
+const (
+	highlightOn  = "\x1b[7m"
+	highlightOff = "\x1b[27m"
+)
+
+// HighlightedRow is a row with the cells matching a search wrapped in
+// highlighting; Matched marks which.
+type HighlightedRow struct {
+	Row     table.Row
+	Matched []bool
+}
+
+// highlightSearch highlights the cells of rows that contain term, ignoring
+// case. The table truncates cells by counting escape codes as text, so
+// matching cells are cut to leave room for them.
+func highlightSearch(rows []table.Row, cols []table.Column, term string) []HighlightedRow {
+	term = strings.ToLower(term)
+	overhead := runewidth.StringWidth(highlightOn + highlightOff)
+	highlighted := make([]HighlightedRow, len(rows))
+	for r, row := range rows {
+		h := HighlightedRow{Row: make(table.Row, len(row)), Matched: make([]bool, len(row))}
+		copy(h.Row, row)
+		for i, cell := range row {
+			if term == "" || i >= len(cols) || cols[i].Width <= 0 || !strings.Contains(strings.ToLower(cell), term) {
+				continue
+			}
+			visible := runewidth.Truncate(cell, max(cols[i].Width-overhead, 1), "…")
+			h.Row[i] = highlightOn + visible + highlightOff
+			h.Matched[i] = true
+		}
+		highlighted[r] = h
+	}
+	return highlighted
+}
+
+// searchRows returns the indexes of the rows with a cell containing term.
+func searchRows(rows []table.Row, term string) []int {
+	term = strings.ToLower(term)
+	var matches []int
+	for r, row := range rows {
+		for _, cell := range row {
+			if strings.Contains(strings.ToLower(cell), term) {
+				matches = append(matches, r)
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// updateSearch handles keys while the search bar is open: enter keeps the
+// highlights and returns to the table, esc clears them.
+func (m Model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.searching = false
+		m.searchInput.Blur()
+		m.searchInput.SetValue("")
+		return m, nil
+	case "enter":
+		m.searching = false
+		m.searchInput.Blur()
+		m.nextMatch(0)
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.searchInput, cmd = m.searchInput.Update(msg)
+	return m, cmd
+}
+
+// nextMatch moves the cursor to the next row matching the search in
+// direction dir, 1 or -1, wrapping around; 0 goes to the first match at or
+// after the cursor.
+func (m *Model) nextMatch(dir int) {
+	term := m.searchInput.Value()
+	if term == "" {
+		return
+	}
+	matches := searchRows(m.table.Rows(), term)
+	if len(matches) == 0 {
+		return
+	}
+	cursor := m.table.Cursor()
+	target := matches[0]
+	switch dir {
+	case -1:
+		target = matches[len(matches)-1]
+		for i := len(matches) - 1; i >= 0; i-- {
+			if matches[i] < cursor {
+				target = matches[i]
+				break
+			}
+		}
+	default:
+		for _, r := range matches {
+			if r > cursor || (dir == 0 && r == cursor) {
+				target = r
+				break
+			}
+		}
+	}
+	m.table.SetCursor(target)
+}
+
+// searchStatus reports the search term and how many rows match it.
+func (m Model) searchStatus() string {
+	term := m.searchInput.Value()
+	if term == "" {
+		return ""
+	}
+	return fmt.Sprintf("search: %s (%d rows)", term, len(searchRows(m.table.Rows(), term)))
+}
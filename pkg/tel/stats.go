@@ -0,0 +1,84 @@
+package tel
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"mcold/tel/config"
+	"mcold/tel/db"
+	"mcold/tel/internal/format"
+)
+
+// StatsOptions configures a RunStats of one table.
+type StatsOptions struct {
+	DB        string
+	Table     string
+	ConfigDir string
+	Writer    io.Writer
+}
+
+// RunStats connects to the database and prints the row count, size,
+// index count and last analyze time of a table.
+func RunStats(opts StatsOptions) error {
+	w := opts.Writer
+	if w == nil {
+		w = os.Stdout
+	}
+	if opts.DB == "" {
+		return errors.New("db is empty")
+	}
+	if opts.Table == "" {
+		return errors.New("table is empty")
+	}
+
+	if opts.ConfigDir != "" {
+		config.SetDir(opts.ConfigDir)
+	}
+	if err := config.Init(); err != nil {
+		return fmt.Errorf("config.Init failed: %w", err)
+	}
+	defer config.Close()
+
+	idDB, err := config.GetDBID(opts.DB)
+	if err != nil {
+		return fmt.Errorf("config.GetDBID failed for dbName=%s: %w", opts.DB, err)
+	}
+	driver, err := config.GetDBDriverByID(idDB)
+	if err != nil {
+		return fmt.Errorf("config.GetDBDriverByID failed for idDB=%d: %w", idDB, err)
+	}
+	connectionString, err := config.GetConnectionStringByID(idDB)
+	if err != nil {
+		return fmt.Errorf("config.GetConnectionStringByID failed for idDB=%d: %w", idDB, err)
+	}
+	initSQL, err := config.GetDBInitSQL(idDB)
+	if err != nil {
+		return fmt.Errorf("config.GetDBInitSQL failed for idDB=%d: %w", idDB, err)
+	}
+	if err := db.Connect(driver, connectionString, initSQL); err != nil {
+		return errors.New(db.ExplainConnectError(driver, err))
+	}
+	defer db.Close()
+
+	stats, err := db.GetTableStatistics(opts.Table, driver)
+	if err != nil {
+		return err
+	}
+	size, analyzed := "unknown", "never"
+	if stats.SizeBytes > 0 {
+		size = format.Apply(strconv.FormatInt(stats.SizeBytes, 10), format.Formatter{Type: "filesize"})
+	}
+	if !stats.LastAnalyzed.IsZero() {
+		analyzed = stats.LastAnalyzed.Format(time.RFC3339)
+	}
+	fmt.Fprintf(w, "table:         %s\n", opts.Table)
+	fmt.Fprintf(w, "rows:          %d\n", stats.RowCount)
+	fmt.Fprintf(w, "size:          %s\n", size)
+	fmt.Fprintf(w, "indexes:       %d\n", stats.IndexCount)
+	fmt.Fprintf(w, "last analyzed: %s\n", analyzed)
+	return nil
+}
@@ -0,0 +1,456 @@
+package tel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+
+	"mcold/tel/config"
+	"mcold/tel/db"
+	"mcold/tel/internal/params"
+	"mcold/tel/internal/sqlsrc"
+	"mcold/tel/internal/theme"
+)
+
+// Options configures a Run of the tel pipeline.
+type Options struct {
+	Item string
+	// SQL names a saved query, an @path to a .sql file or - for stdin. With
+	// Query set it is only a display name.
+	SQL string
+	// Query is inline SQL run instead of a saved query.
+	Query  string
+	DB     string
+	Filter string
+	// Args is the path to a JSON file with placeholder values.
+	Args string
+	UID  string
+	// View is "r" for rows or "c" for the vertical view; empty uses the
+	// query's.
+	View  string
+	Theme string
+	// Height overrides the table height from the query config when > 0.
+	Height int
+	// MaxWidth overrides the column width cap from the query config when > 0.
+	MaxWidth int
+	// Mouse enables row selection by click and wheel scrolling. The TUI then
+	// runs in the alternate screen so click coordinates match the table.
+	Mouse bool
+	// Live re-runs the filter shortly after typing stops instead of waiting
+	// for enter.
+	Live bool
+	// CacheRows keeps results of up to this many rows in memory and applies
+	// simple "col = 'text'" filters to them without querying again. 0
+	// disables the cache.
+	CacheRows int
+	// UndoDepth is how many config saves ctrl+z can undo; 0 disables undo.
+	UndoDepth int
+	// NoColor drops the colors from the TUI, as NO_COLOR asks.
+	NoColor bool
+	// SaveState records the invocation, with the filter and uid it ended
+	// with, for tel last.
+	SaveState bool
+	// DryRun prints the composed SQL to Writer instead of running it.
+	DryRun bool
+	// ConfigDir overrides the ~/.tel directory holding tel.db.
+	ConfigDir string
+	// Writer receives the TUI output; os.Stdout when nil.
+	Writer io.Writer
+}
+
+// applyColumnWidths sets the configured widths, keyed by column title or
+// its alias, 20 for the others, capped at maxWidth when it's > 0. The
+// table truncates longer cells with "…".
+func applyColumnWidths(columns []table.Column, widths map[string]int, aliases map[string]string, maxWidth int) []table.Column {
+	for i := range columns {
+		fieldName := columns[i].Title
+		width, ok := widths[fieldName]
+		if alias := aliases[fieldName]; !ok && alias != "" {
+			width, ok = widths[alias]
+		}
+		if ok {
+			columns[i].Width = width
+		} else {
+			columns[i].Width = 20
+		}
+		if maxWidth > 0 {
+			columns[i].Width = min(columns[i].Width, maxWidth)
+		}
+	}
+	return columns
+}
+
+// substituteArgs replaces the placeholders in sqlQuery with the values
+// from the JSON file at path.
+func substituteArgs(sqlQuery, path string) (string, error) {
+	args, err := params.Load(path)
+	if err != nil {
+		return "", err
+	}
+	return params.Substitute(sqlQuery, args)
+}
+
+// disableColor strips the colors from the styles shared by tel's views.
+// lipgloss drops bold and reverse video along with colors when it sees
+// NO_COLOR, which would hide the selected row, so it keeps plain ANSI.
+func disableColor() {
+	lipgloss.SetColorProfile(termenv.ANSI)
+	statusStyle = theme.ApplyNoColor(statusStyle)
+	errorStyle = theme.ApplyNoColor(errorStyle)
+	footerStyle = theme.ApplyNoColor(footerStyle)
+	helpShade = lipgloss.NoColor{}
+	for kind, style := range diffStyles {
+		diffStyles[kind] = theme.ApplyNoColor(style)
+	}
+}
+
+// Run connects to the database, runs the query and shows the interactive
+// table until the user quits.
+func Run(opts Options) error {
+	w := opts.Writer
+	if w == nil {
+		w = os.Stdout
+	}
+
+	if opts.Item == "" {
+		return errors.New("item is empty")
+	}
+	if opts.SQL == "" && opts.Query == "" {
+		return errors.New("sql and query are empty")
+	}
+	if opts.DB == "" {
+		return errors.New("db is empty")
+	}
+
+	if opts.ConfigDir != "" {
+		config.SetDir(opts.ConfigDir)
+	}
+	if err := config.Init(); err != nil {
+		return fmt.Errorf("config.Init failed: %w", err)
+	}
+	defer config.Close()
+	log.Println("Config initialized successfully")
+
+	telDir, err := config.GetDir()
+	if err != nil {
+		return fmt.Errorf("config.GetDir failed: %w", err)
+	}
+	th, err := theme.Load(opts.Theme, filepath.Join(telDir, "theme.json"))
+	if err != nil {
+		return err
+	}
+	statusStyle = th.StatusStyle()
+	if opts.NoColor {
+		disableColor()
+	}
+
+	idDB, err := config.GetDBID(opts.DB)
+	if err != nil {
+		return fmt.Errorf("config.GetDBID failed for dbName=%s: %w", opts.DB, err)
+	}
+	log.Printf("idDB: %d", idDB)
+
+	idItem, err := config.GetItemID(opts.Item)
+	if err != nil {
+		return fmt.Errorf("config.GetItemID failed for itemName=%s: %w", opts.Item, err)
+	}
+	log.Printf("idItem: %d", idItem)
+
+	driver, err := config.GetDBDriverByID(idDB)
+	if err != nil {
+		return fmt.Errorf("config.GetDBDriverByID failed for idDB=%d: %w", idDB, err)
+	}
+	log.Printf("driver: %s", driver)
+
+	connectionString, err := config.GetConnectionStringByID(idDB)
+	if err != nil {
+		return fmt.Errorf("config.GetConnectionStringByID failed for idDB=%d: %w", idDB, err)
+	}
+	log.Printf("connectionString: %s", connectionString)
+
+	initSQL, err := config.GetDBInitSQL(idDB)
+	if err != nil {
+		return fmt.Errorf("config.GetDBInitSQL failed for idDB=%d: %w", idDB, err)
+	}
+
+	sqlQuery, idQuery, queryConfig, err := loadQuery(opts.SQL, opts.Query)
+	if err != nil {
+		return err
+	}
+	log.Printf("idQuery: %d", idQuery)
+	log.Printf("sqlQuery: %s", sqlQuery)
+
+	// Placeholders -args doesn't supply are asked for in the TUI. -dry-run
+	// has none to ask in, so there they fail as usual.
+	var args any
+	if opts.Args != "" {
+		if args, err = params.Load(opts.Args); err != nil {
+			return err
+		}
+	}
+	missing := params.Missing(sqlQuery, args)
+	if args != nil && (len(missing) == 0 || opts.DryRun) {
+		sqlQuery, err = params.Substitute(sqlQuery, args)
+		if err != nil {
+			return err
+		}
+		log.Println(sqlQuery)
+	} else if opts.DryRun {
+		if err := params.RequireNamed(sqlQuery, nil); err != nil {
+			return err
+		}
+	}
+
+	widths, aliases, tblHeight := queryConfig.Widths, queryConfig.Aliases, queryConfig.Height
+	log.Printf("widths: %v, aliases: %v, tblHeight: %d", widths, aliases, tblHeight)
+	if opts.Height > 0 {
+		tblHeight = opts.Height
+		log.Printf("tblHeight overridden by flag: %d", tblHeight)
+	}
+	maxWidth := queryConfig.MaxWidth
+	if opts.MaxWidth > 0 {
+		maxWidth = opts.MaxWidth
+	}
+	db.SetOptions(db.Options{
+		BinaryEncoding: queryConfig.BinaryEncoding,
+		TimeFormat:     queryConfig.TimeFormat,
+		BoolFormat:     queryConfig.BoolFormat,
+		NullText:       queryConfig.NullText,
+		EmptyText:      queryConfig.EmptyText,
+	})
+
+	view := opts.View
+	if view == "" && (opts.Query != "" || sqlsrc.IsSource(opts.SQL)) {
+		view = "r"
+	}
+	if view == "" {
+		view, err = config.GetQueryView(opts.SQL)
+		if err != nil {
+			return fmt.Errorf("config.GetQueryView failed for sqlName=%s: %w", opts.SQL, err)
+		}
+	}
+	log.Printf("view: %s", view)
+
+	// Load filter from instance table if uid is provided and filter is empty
+	filter := opts.Filter
+	if filter == "" && opts.UID != "" {
+		loadedFilter, err := config.GetFilterByUID(opts.UID, idQuery)
+		if err != nil {
+			log.Printf("WARN: GetFilterByUID failed for uid=%s, idQuery=%d: %v", opts.UID, idQuery, err)
+		} else if loadedFilter != "" {
+			filter = loadedFilter
+			log.Printf("Filter loaded from instance: %q", filter)
+		}
+	}
+
+	if opts.DryRun {
+		if normalizeFilter(filter) != "" && !filterable(sqlQuery) {
+			return errNotFilterable
+		}
+		fmt.Fprintln(w, composeQuery(sqlQuery, filter))
+		return nil
+	}
+
+	if err := db.Connect(driver, connectionString, initSQL); err != nil {
+		log.Printf("ERROR: database.Connect failed for driver=%s: %v", driver, err)
+		return errors.New(db.ExplainConnectError(driver, err))
+	}
+	log.Println("Database connected successfully")
+	defer db.Close()
+
+	// The initial query runs inside the program, behind a spinner, so slow
+	// queries don't leave a blank terminal. Quitting cancels it.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	load := func(sqlQuery string) (Model, error) {
+		start := time.Now()
+		rows, columns, types, err := db.GetTypedContentContext(ctx, sqlQuery)
+		if err != nil {
+			return Model{}, fmt.Errorf("database.GetContent failed: %w", err)
+		}
+		queryTime := time.Since(start)
+		log.Printf("Retrieved %d rows, %d columns in %s", len(rows), len(columns), queryTime)
+
+		if len(rows) == 0 || len(columns) == 0 {
+			return Model{}, errors.New("no rows or columns retrieved from database")
+		}
+
+		// Cache before formatting; FilterContent formats filtered rows again.
+		// The cache takes empty cells for possible NULLs, so it's off when
+		// they show as text.
+		var cache *resultCache
+		if len(rows) <= opts.CacheRows && queryConfig.NullText == "" && queryConfig.EmptyText == "" {
+			cache = newResultCache(rows, columns, types)
+			log.Printf("Caching %d rows for client-side filtering", len(rows))
+		}
+
+		sortRows(rows, columns, queryConfig.SortBy)
+		applyFormatters(rows, columns, queryConfig.Formatters)
+		applyNumberFormats(rows, columns, queryConfig.Format)
+
+		columns = applyColumnWidths(columns, widths, aliases, maxWidth)
+		log.Printf("Applied column widths: %d columns processed", len(columns))
+
+		rows, columns = reorderColumns(rows, columns, displayOrder(queryConfig))
+
+		if tblHeight == 0 {
+			tblHeight = 10
+			log.Println("tblHeight was 0, set to default 10")
+		}
+
+		// Shrink to fit short results; longer ones scroll at the requested height.
+		if len(rows) < tblHeight {
+			tblHeight = len(rows)
+			log.Printf("tblHeight adjusted to %d (rows count)", tblHeight)
+		}
+
+		log.Printf("Final tblHeight: %d", tblHeight)
+
+		t := table.New(
+			table.WithColumns(columns),
+			table.WithRows(rows),
+			table.WithFocused(true),
+		)
+
+		// tblHeight counts data rows, while SetHeight takes the total height and
+		// subtracts the header as rendered with the current styles. The themed
+		// header has a bottom border, so set the styles first and add its height.
+		styles := th.TableStyles()
+		if opts.NoColor {
+			styles.Header = theme.ApplyNoColor(styles.Header)
+			styles.Cell = theme.ApplyNoColor(styles.Cell)
+			// Reverse video marks the selected row instead of its colors.
+			styles.Selected = theme.ApplyNoColor(styles.Selected).Reverse(true)
+		}
+		t.SetStyles(styles)
+		t.SetHeight(tblHeight + lipgloss.Height(styles.Header.Render("")))
+		baseStyle = th.BorderStyle()
+		if opts.NoColor {
+			baseStyle = theme.ApplyNoColor(baseStyle)
+		}
+
+		ti := textinput.New()
+		ti.CharLimit = 500
+		ti.Width = 1000
+
+		if filter != "" {
+			ti.SetValue(filter)
+			log.Printf("Initial filter applied: %q", filter)
+		}
+
+		m := NewModel(t, ti, opts.Item, opts.SQL, sqlQuery, idDB, idQuery, tblHeight, aliases, filter, opts.UID, view)
+		m.live = opts.Live
+		m.cache = cache
+		m.dbName = opts.DB
+		m.driver = driver
+		m.undoDepth = opts.UndoDepth
+		m.maxWidth = maxWidth
+		m.nullText, m.emptyText = queryConfig.NullText, queryConfig.EmptyText
+		m.queryTime = queryTime
+		m.frozen = queryConfig.FrozenCols
+		log.Printf("UI Model created: itemName=%s, sqlName=%s, idDB=%d, idQuery=%d, tblHeight=%d, uid=%s, view=%s",
+			opts.Item, opts.SQL, idDB, idQuery, tblHeight, opts.UID, view)
+
+		if filter != "" {
+			rows, cols, elapsed, err := m.filterContent(context.Background(), filter)
+			if err != nil {
+				log.Printf("WARN: initial filter %q not applied: %v", filter, err)
+			} else if len(rows) > 0 {
+				m.queryTime = elapsed
+				m.setContent(rows, cols)
+				log.Printf("Filter applied: %d rows after filtering", len(rows))
+			}
+		} else if view == "c" {
+			// Apply vertical view for column mode without filter
+			rows, cols := ToVerticalView(rows, db.NamedColumns(columns, aliases))
+			m.setContent(rows, cols)
+			log.Printf("Vertical view applied: %d rows", len(rows))
+		}
+
+		// Select row by hash if uid is provided
+		if opts.UID != "" {
+			hash, err := config.GetHashByUID(opts.UID, idQuery)
+			if err != nil {
+				log.Printf("WARN: GetHashByUID failed for uid=%s, idQuery=%d: %v", opts.UID, idQuery, err)
+			} else {
+				log.Printf("Looking for row with hash=%s", hash)
+				m.SelectRowByHash(hash)
+			}
+		}
+		return m, nil
+	}
+
+	programOpts := []tea.ProgramOption{tea.WithOutput(w)}
+	if opts.SQL == sqlsrc.Stdin {
+		// Stdin held the query, so read keys from the terminal.
+		programOpts = append(programOpts, tea.WithInputTTY())
+	}
+	if opts.Mouse {
+		programOpts = append(programOpts, tea.WithAltScreen(), tea.WithMouseCellMotion())
+	}
+	var first tea.Model = newLoadingModel(func() (Model, error) { return load(sqlQuery) })
+	if len(missing) > 0 {
+		log.Printf("Prompting for placeholders: %v", missing)
+		first = newPromptModel(missing, func(values map[string]any) (tea.Model, error) {
+			if named, ok := args.(map[string]any); ok {
+				for k, v := range named {
+					values[k] = v
+				}
+			}
+			query, err := params.Substitute(sqlQuery, values)
+			if err != nil {
+				return nil, err
+			}
+			log.Println(query)
+			return newLoadingModel(func() (Model, error) { return load(query) }), nil
+		})
+	}
+	final, err := runProgram(tea.NewProgram(first, programOpts...))
+	if err != nil {
+		return fmt.Errorf("tea.NewProgram.Run failed: %w", err)
+	}
+	if lm, ok := final.(loadingModel); ok && lm.err != nil {
+		return lm.err
+	}
+	if m, ok := final.(Model); ok && opts.SaveState && opts.SQL != sqlsrc.Stdin {
+		run := config.LastRun{DB: opts.DB, Item: opts.Item, SQL: opts.SQL, Query: opts.Query, Filter: m.appliedFilter, UID: m.uid}
+		if err := config.SaveLastRun(run); err != nil {
+			log.Printf("WARN: saving the last run failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// runProgram runs p, quitting it on SIGHUP too so callers' deferred closes
+// run when the terminal goes away. Bubbletea itself quits on SIGINT/SIGTERM.
+func runProgram(p *tea.Program) (tea.Model, error) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	defer signal.Stop(sig)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case s := <-sig:
+			log.Printf("Received %v, quitting", s)
+			p.Quit()
+		case <-done:
+		}
+	}()
+
+	return p.Run()
+}